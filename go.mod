@@ -1,9 +0,0 @@
-module github.com/Sigafoos/wobbotfet
-
-go 1.12
-
-require (
-	github.com/Sigafoos/iv/model v0.0.0-20190608151455-655008668974
-	github.com/bwmarrin/discordgo v0.19.0
-	github.com/spf13/viper v1.4.0
-)
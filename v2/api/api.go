@@ -1,17 +1,67 @@
 package api
 
 import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
 	"github.com/Sigafoos/wobbotfet/bot"
+	"github.com/Sigafoos/wobbotfet/config"
+	"github.com/bwmarrin/discordgo"
 )
 
 // An API is the handler for the wob dashboard api.
 type API struct {
-	bot *bot.Bot
+	bot      *bot.Bot
+	config   config.API
+	sessions *sessions
+	// http is used to talk to Discord when logging in
+	http  *http.Client
+	ready readiness
 }
 
 // New returns a new API.
-func New(b *bot.Bot) *API {
+func New(b *bot.Bot, c config.API) *API {
 	return &API{
-		bot: b,
+		bot:      b,
+		config:   c,
+		sessions: newSessions(),
+		http:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// writeJSON writes v as the response.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	writeJSONStatus(w, http.StatusOK, v)
+}
+
+// writeJSONStatus writes v as the response, with a status code.
+func writeJSONStatus(w http.ResponseWriter, status int, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("error marshalling json: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(b)
+}
+
+// writeError responds to an error from the bot: 404 if the service it needs isn't configured, 502 if Discord said no,
+// otherwise 500.
+func writeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, bot.ErrNotConfigured) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	var restErr *discordgo.RESTError
+	if errors.As(err, &restErr) {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
 	}
+	log.Println(err)
+	w.WriteHeader(http.StatusInternalServerError)
 }
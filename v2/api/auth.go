@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// A Principal is who's making a request.
+type Principal struct {
+	// User is their Discord user ID. It's empty for the API token.
+	User string `json:"user"`
+	// Owner can see and do everything.
+	Owner bool `json:"owner"`
+	// manages are the servers a logged in user can manage.
+	manages map[string]bool
+}
+
+type principalKey struct{}
+
+// principal returns who's making a request that's been through one of the auth wrappers.
+func principal(r *http.Request) *Principal {
+	p, _ := r.Context().Value(principalKey{}).(*Principal)
+	return p
+}
+
+// authenticate returns who made the request, or nil if they didn't say (or said wrong). The API token and session
+// IDs are accepted as bearer tokens; session IDs are also accepted as the session cookie.
+func (a *API) authenticate(r *http.Request) *Principal {
+	if a.config.Open() {
+		return &Principal{Owner: true}
+	}
+
+	var token string
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+		if a.config.Token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(a.config.Token)) == 1 {
+			return &Principal{Owner: true}
+		}
+	} else if cookie, err := r.Cookie(sessionCookie); err == nil {
+		token = cookie.Value
+	}
+
+	session, ok := a.sessions.get(token)
+	if !ok {
+		return nil
+	}
+	return &Principal{
+		User:    session.user,
+		Owner:   a.bot.IsOwner(session.user),
+		manages: session.manages,
+	}
+}
+
+// authorized runs h if allow lets the principal through: 401 if nobody's logged in, 403 if allow says no.
+func (a *API) authorized(h http.HandlerFunc, allow func(*Principal, *http.Request) bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p := a.authenticate(r)
+		if p == nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if !allow(p, r) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		h(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, p)))
+	}
+}
+
+// Authenticated lets anyone who's logged in through. The handler decides what they can see.
+func (a *API) Authenticated(h http.HandlerFunc) http.HandlerFunc {
+	return a.authorized(h, func(*Principal, *http.Request) bool {
+		return true
+	})
+}
+
+// Owner only lets the owner through.
+func (a *API) Owner(h http.HandlerFunc) http.HandlerFunc {
+	return a.authorized(h, func(p *Principal, r *http.Request) bool {
+		return p.Owner
+	})
+}
+
+// GuildAdmin only lets the owner and people who can manage the request's {server} through.
+func (a *API) GuildAdmin(h http.HandlerFunc) http.HandlerFunc {
+	return a.authorized(h, func(p *Principal, r *http.Request) bool {
+		return a.canManage(p, mux.Vars(r)["server"])
+	})
+}
+
+// canManage reports whether the principal can see and change a server's data. For a logged in user that's going by
+// what Discord said when they logged in, so a change to their permissions takes a new login.
+func (a *API) canManage(p *Principal, server string) bool {
+	return p.Owner || p.manages[server]
+}
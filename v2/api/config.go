@@ -0,0 +1,57 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// GetConfig returns the configuration for a server.
+func (a *API) GetConfig(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	server, ok := vars["server"]
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	b, err := json.Marshal(a.bot.GuildConfig(server))
+	if err != nil {
+		log.Printf("error marshalling json: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Write(b)
+}
+
+// PutConfig updates the configuration for a server. Settings missing from the body are left as they are.
+func (a *API) PutConfig(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	server, ok := vars["server"]
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	c := a.bot.GuildConfig(server)
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := a.bot.SetGuildConfig(server, c); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// as it was saved, ie with aliases turned into their commands
+	c = a.bot.GuildConfig(server)
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		log.Printf("error marshalling json: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Write(b)
+}
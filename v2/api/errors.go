@@ -0,0 +1,10 @@
+package api
+
+import (
+	"net/http"
+)
+
+// GetErrors returns the most recent errors wobbotfet ran into, newest first.
+func (a *API) GetErrors(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, a.bot.RecentErrors())
+}
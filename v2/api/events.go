@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// keepAlive is how often a comment is sent on a quiet stream, so proxies don't time it out.
+const keepAlive = 30 * time.Second
+
+// GetEvents streams commands, backend errors, panics and PM conversations as they happen, as server-sent events.
+// Each event's name is its type and its data is the JSON event. With `?guild=` it's only what happens in that guild,
+// which is the only way anyone but the owner can use it.
+func (a *API) GetEvents(w http.ResponseWriter, r *http.Request) {
+	guild := r.URL.Query().Get("guild")
+	p := principal(r)
+	if (guild == "" && !p.Owner) || (guild != "" && !a.canManage(p, guild)) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming isn't supported", http.StatusInternalServerError)
+		return
+	}
+
+	events, done := a.bot.Subscribe(guild)
+	defer done()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// nginx buffers responses unless it's told not to
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			b, err := json.Marshal(e)
+			if err != nil {
+				log.Printf("error marshalling json: %s", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, b); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
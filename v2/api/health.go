@@ -0,0 +1,98 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// pingTimeout is how long a readiness check waits on each service.
+const pingTimeout = 5 * time.Second
+
+// readyCacheLength is how long a readiness check's result is used for, so checking often doesn't mean pinging every
+// service as often.
+const readyCacheLength = 5 * time.Second
+
+// readiness is the result of the last time the services were pinged.
+type readiness struct {
+	sync.Mutex
+	checked  time.Time
+	ok       bool
+	services map[string]string
+}
+
+// A HealthResponse is the state of the gateway connection and, for readiness, each configured service.
+type HealthResponse struct {
+	OK      bool   `json:"ok"`
+	Gateway string `json:"gateway"`
+	// Services is "ok" or "unavailable", by service. Why one's unavailable is only logged, since this is public.
+	Services map[string]string `json:"services,omitempty"`
+}
+
+// Health reports whether the bot is connected to Discord. It's 503 if it isn't.
+func (a *API) Health(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, a.health())
+}
+
+// Ready reports whether the bot is connected to Discord and can reach every configured service. It's 503 if not.
+func (a *API) Ready(w http.ResponseWriter, r *http.Request) {
+	health := a.health()
+	ok, services := a.pingServices()
+	health.OK = health.OK && ok
+	health.Services = services
+	writeHealth(w, health)
+}
+
+// pingServices returns whether every service could be reached, and "ok" or "unavailable" for each. They're only
+// pinged again once the last result's old, and checks while they're being pinged wait on that result.
+func (a *API) pingServices() (bool, map[string]string) {
+	a.ready.Lock()
+	defer a.ready.Unlock()
+	if time.Since(a.ready.checked) < readyCacheLength {
+		return a.ready.ok, a.ready.services
+	}
+
+	// not the request's context, since other requests use the result too
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	ok := true
+	services := make(map[string]string)
+	for service, err := range a.bot.PingServices(ctx) {
+		services[service] = "ok"
+		if err != nil {
+			log.Printf("readiness check: %s is unavailable: %s", service, err)
+			ok = false
+			services[service] = "unavailable"
+		}
+	}
+
+	a.ready.checked = time.Now()
+	a.ready.ok = ok
+	a.ready.services = services
+	return ok, services
+}
+
+func (a *API) health() *HealthResponse {
+	if a.bot.Connected() {
+		return &HealthResponse{OK: true, Gateway: "connected"}
+	}
+	return &HealthResponse{OK: false, Gateway: "disconnected"}
+}
+
+func writeHealth(w http.ResponseWriter, health *HealthResponse) {
+	b, err := json.Marshal(health)
+	if err != nil {
+		log.Printf("error marshalling json: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !health.OK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	w.Write(b)
+}
@@ -0,0 +1,271 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	sessionCookie = "wob_session"
+	stateCookie   = "wob_oauth_state"
+	// sessionLength is how long a login lasts.
+	sessionLength = 7 * 24 * time.Hour
+)
+
+// manageGuild is the permission to manage a server (or be its admin), which is what it takes to use the dashboard for
+// it.
+const manageGuild = discordgo.PermissionManageServer | discordgo.PermissionAdministrator
+
+// A session is a logged in user.
+type session struct {
+	user string
+	// manages are the servers they could manage when they logged in, from Discord, so checking doesn't cost the bot
+	// any of its own rate limit.
+	manages map[string]bool
+	expires time.Time
+}
+
+// sessions are the logged in users, by session ID.
+type sessions struct {
+	sync.Mutex
+	sessions map[string]session
+}
+
+func newSessions() *sessions {
+	return &sessions{sessions: make(map[string]session)}
+}
+
+// create starts a session for user, returning its ID.
+func (s *sessions) create(user string, manages map[string]bool) (string, error) {
+	id, err := randomID()
+	if err != nil {
+		return "", err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	// clear out the old ones while we're here
+	for old, session := range s.sessions {
+		if time.Now().After(session.expires) {
+			delete(s.sessions, old)
+		}
+	}
+	s.sessions[id] = session{user: user, manages: manages, expires: time.Now().Add(sessionLength)}
+	return id, nil
+}
+
+// get returns a session, if it exists and hasn't expired.
+func (s *sessions) get(id string) (session, bool) {
+	if id == "" {
+		return session{}, false
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	session, ok := s.sessions[id]
+	if !ok || time.Now().After(session.expires) {
+		return session, false
+	}
+	return session, true
+}
+
+func (s *sessions) delete(id string) {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.sessions, id)
+}
+
+func randomID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Login sends the user to Discord to log in. Discord sends them back to Callback.
+func (a *API) Login(w http.ResponseWriter, r *http.Request) {
+	if !a.config.OAuth.Enabled() {
+		http.Error(w, "logging in with Discord isn't configured", http.StatusNotFound)
+		return
+	}
+
+	state, err := randomID()
+	if err != nil {
+		log.Printf("error generating oauth state: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	a.setCookie(w, stateCookie, state, 10*time.Minute)
+
+	query := url.Values{
+		"client_id":     {a.config.OAuth.ClientID},
+		"redirect_uri":  {a.config.OAuth.RedirectURL},
+		"response_type": {"code"},
+		"scope":         {"identify guilds"},
+		"state":         {state},
+	}
+	http.Redirect(w, r, discordgo.EndpointOauth2+"authorize?"+query.Encode(), http.StatusFound)
+}
+
+// Callback finishes logging in: it finds out who the user is from Discord, starts a session and sends them to the
+// dashboard. The session ID is set as a cookie, and can also be used as a bearer token.
+func (a *API) Callback(w http.ResponseWriter, r *http.Request) {
+	if !a.config.OAuth.Enabled() {
+		http.Error(w, "logging in with Discord isn't configured", http.StatusNotFound)
+		return
+	}
+
+	state, err := r.Cookie(stateCookie)
+	if err != nil || state.Value == "" || state.Value != r.URL.Query().Get("state") {
+		http.Error(w, "the login has expired; try again", http.StatusBadRequest)
+		return
+	}
+	a.setCookie(w, stateCookie, "", -1)
+
+	code := r.URL.Query().Get("code")
+	if code == "" {
+		http.Error(w, "Discord didn't log you in", http.StatusBadRequest)
+		return
+	}
+
+	user, manages, err := a.discordUser(code)
+	if err != nil {
+		log.Printf("error logging in with discord: %s", err)
+		http.Error(w, "couldn't log in with Discord", http.StatusBadGateway)
+		return
+	}
+
+	id, err := a.sessions.create(user, manages)
+	if err != nil {
+		log.Printf("error creating session: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	a.setCookie(w, sessionCookie, id, sessionLength)
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+// Logout ends the session.
+func (a *API) Logout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(sessionCookie); err == nil {
+		a.sessions.delete(cookie.Value)
+	}
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		a.sessions.delete(strings.TrimPrefix(auth, "Bearer "))
+	}
+	a.setCookie(w, sessionCookie, "", -1)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Me returns who's logged in.
+func (a *API) Me(w http.ResponseWriter, r *http.Request) {
+	b, err := json.Marshal(principal(r))
+	if err != nil {
+		log.Printf("error marshalling json: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Write(b)
+}
+
+// discordUser exchanges an authorization code for a token, and returns the ID of the user it's for and the servers
+// they can manage.
+func (a *API) discordUser(code string) (string, map[string]bool, error) {
+	resp, err := a.http.PostForm(discordgo.EndpointOauth2+"token", url.Values{
+		"client_id":     {a.config.OAuth.ClientID},
+		"client_secret": {a.config.OAuth.ClientSecret},
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {a.config.OAuth.RedirectURL},
+		"scope":         {"identify guilds"},
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("exchanging code: got %v", resp.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", nil, fmt.Errorf("error decoding token: %w", err)
+	}
+
+	var user discordgo.User
+	if err := a.discordGet(token.AccessToken, discordgo.EndpointUser("@me"), &user); err != nil {
+		return "", nil, fmt.Errorf("getting user: %w", err)
+	}
+
+	// Discord returns up to 200, which is more servers than anyone should be managing
+	var guilds []struct {
+		ID    string `json:"id"`
+		Owner bool   `json:"owner"`
+		// Permissions is a number, or a string of one in newer versions of the API.
+		Permissions json.RawMessage `json:"permissions"`
+	}
+	if err := a.discordGet(token.AccessToken, discordgo.EndpointUserGuilds("@me"), &guilds); err != nil {
+		return "", nil, fmt.Errorf("getting servers: %w", err)
+	}
+	manages := make(map[string]bool)
+	for _, g := range guilds {
+		perms, _ := strconv.ParseInt(strings.Trim(string(g.Permissions), `"`), 10, 64)
+		if g.Owner || perms&manageGuild != 0 {
+			manages[g.ID] = true
+		}
+	}
+	return user.ID, manages, nil
+}
+
+// discordGet gets something from Discord as the user the token is for.
+func (a *API) discordGet(token, endpoint string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := a.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("got %v", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("error decoding: %w", err)
+	}
+	return nil
+}
+
+// setCookie sets (or, with a negative maxAge, clears) a cookie. It's only sent over https if the API is behind https,
+// going by the redirect URL.
+func (a *API) setCookie(w http.ResponseWriter, name, value string, maxAge time.Duration) {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   int(maxAge.Seconds()),
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(a.config.OAuth.RedirectURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	}
+	if maxAge < 0 {
+		cookie.MaxAge = -1
+	}
+	http.SetCookie(w, cookie)
+}
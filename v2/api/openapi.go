@@ -0,0 +1,683 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/Sigafoos/wobbotfet/bot"
+	"github.com/Sigafoos/wobbotfet/errorlog"
+	"github.com/Sigafoos/wobbotfet/eventlog"
+	"github.com/Sigafoos/wobbotfet/guildconfig"
+	"github.com/bwmarrin/discordgo"
+	"github.com/gorilla/mux"
+)
+
+// schemaTypes are the types the spec's schemas describe, so CheckSpec can make sure they have the same fields.
+// SentMessage is left out because it only describes some of discordgo.Message.
+var schemaTypes = map[string]interface{}{
+	"Principal":       Principal{},
+	"Server":          Server{},
+	"ServerDetail":    ServerDetail{},
+	"Role":            discordgo.Role{},
+	"GuildConfig":     guildconfig.Config{},
+	"WantRole":        WantRole{},
+	"Player":          Player{},
+	"Friendship":      Friendship{},
+	"FriendGraph":     FriendGraph{},
+	"Usage":           eventlog.Usage{},
+	"CommandUsage":    eventlog.CommandUsage{},
+	"StreamEvent":     bot.StreamEvent{},
+	"Message":         bot.Message{},
+	"BroadcastResult": bot.BroadcastResult{},
+	"ReloadResponse":  ReloadResponse{},
+	"Error":           errorlog.Error{},
+}
+
+// GetOpenAPI returns the OpenAPI spec.
+func (a *API) GetOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(Spec))
+}
+
+// CheckSpec returns an error if the spec doesn't match the routes registered on r (by method and path) or the types
+// the API responds with (by JSON field). The tests run it, so the spec can't be forgotten about.
+func CheckSpec(r *mux.Router) error {
+	var spec struct {
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal([]byte(Spec), &spec); err != nil {
+		return fmt.Errorf("error decoding the OpenAPI spec: %w", err)
+	}
+
+	documented := make(map[string]bool)
+	for path, operations := range spec.Paths {
+		for method := range operations {
+			if method != "parameters" {
+				documented[strings.ToUpper(method)+" "+path] = true
+			}
+		}
+	}
+	registered := make(map[string]bool)
+	err := r.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return fmt.Errorf("%s has no methods", path)
+		}
+		for _, method := range methods {
+			registered[method+" "+path] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	for route := range registered {
+		if !documented[route] {
+			problems = append(problems, route+" isn't in the OpenAPI spec")
+		}
+	}
+	for route := range documented {
+		if !registered[route] {
+			problems = append(problems, route+" is in the OpenAPI spec but isn't a route")
+		}
+	}
+
+	for name, v := range schemaTypes {
+		schema, ok := spec.Components.Schemas[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("there's no %s schema in the OpenAPI spec", name))
+			continue
+		}
+		fields := make(map[string]bool)
+		for _, field := range jsonFields(reflect.TypeOf(v)) {
+			fields[field] = true
+			if _, ok := schema.Properties[field]; !ok {
+				problems = append(problems, fmt.Sprintf("the %s schema is missing %s", name, field))
+			}
+		}
+		for property := range schema.Properties {
+			if !fields[property] {
+				problems = append(problems, fmt.Sprintf("the %s schema has %s, which isn't a field", name, property))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("the OpenAPI spec is out of date:\n%s", strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+// jsonFields returns the names a struct's fields are marshalled to JSON as, including those of embedded structs.
+func jsonFields(t reflect.Type) []string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if f.Anonymous && tag == "" {
+			fields = append(fields, jsonFields(f.Type)...)
+			continue
+		}
+		if f.PkgPath != "" || tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, name)
+	}
+	return fields
+}
+
+// Spec is the OpenAPI spec for the dashboard API. The client in apiclient is generated from it. It's a raw string, so
+// no backticks in here.
+const Spec = `{
+  "openapi": "3.0.3",
+  "info": {
+    "title": "wobbotfet dashboard API",
+    "description": "Manage wobbotfet and see what it's up to. Requests need the API token as a bearer token, or a session from logging in with Discord (as the wob_session cookie or a bearer token). The owner can see and do everything; anyone else only sees the servers they can manage.",
+    "version": "2"
+  },
+  "security": [
+    {"bearer": []},
+    {"session": []}
+  ],
+  "paths": {
+    "/": {
+      "get": {
+        "operationId": "getDashboard",
+        "summary": "The admin dashboard",
+        "security": [],
+        "responses": {
+          "200": {"description": "The dashboard page", "content": {"text/html": {}}}
+        }
+      }
+    },
+    "/openapi.json": {
+      "get": {
+        "operationId": "getOpenAPI",
+        "summary": "This document",
+        "security": [],
+        "responses": {
+          "200": {"description": "The OpenAPI document", "content": {"application/json": {}}}
+        }
+      }
+    },
+    "/auth/login": {
+      "get": {
+        "operationId": "login",
+        "summary": "Log in with Discord",
+        "description": "Redirects to Discord, which redirects back to /auth/callback.",
+        "security": [],
+        "responses": {
+          "302": {"description": "Off to Discord"},
+          "404": {"description": "Logging in with Discord isn't configured"}
+        }
+      }
+    },
+    "/auth/callback": {
+      "get": {
+        "operationId": "loginCallback",
+        "summary": "Finish logging in with Discord",
+        "description": "Sets the session cookie and redirects to the dashboard.",
+        "security": [],
+        "parameters": [
+          {"name": "code", "in": "query", "required": true, "schema": {"type": "string"}},
+          {"name": "state", "in": "query", "required": true, "schema": {"type": "string"}}
+        ],
+        "responses": {
+          "302": {"description": "Logged in"},
+          "400": {"description": "The login expired, or Discord didn't log you in"},
+          "502": {"description": "Discord didn't say who you are"}
+        }
+      }
+    },
+    "/auth/logout": {
+      "post": {
+        "operationId": "logout",
+        "summary": "End the session",
+        "security": [],
+        "responses": {
+          "204": {"description": "Logged out"}
+        }
+      }
+    },
+    "/auth/me": {
+      "get": {
+        "operationId": "getMe",
+        "summary": "Who you're logged in as",
+        "responses": {
+          "200": {"description": "You", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Principal"}}}},
+          "401": {"$ref": "#/components/responses/Unauthorized"}
+        }
+      }
+    },
+    "/servers": {
+      "get": {
+        "operationId": "getServers",
+        "summary": "The servers wobbotfet is in that you can manage",
+        "parameters": [
+          {"name": "name", "in": "query", "description": "Only servers with names containing this", "schema": {"type": "string"}},
+          {"name": "permission", "in": "query", "description": "Only servers wobbotfet has this (parsed) permission in", "schema": {"type": "string"}},
+          {"name": "sort", "in": "query", "schema": {"type": "string", "enum": ["name", "members", "joined"], "default": "name"}},
+          {"name": "order", "in": "query", "description": "desc reverses the sort", "schema": {"type": "string", "enum": ["asc", "desc"]}}
+        ],
+        "responses": {
+          "200": {"description": "The servers", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Server"}}}}},
+          "400": {"description": "The sort isn't one of the options"},
+          "401": {"$ref": "#/components/responses/Unauthorized"}
+        }
+      }
+    },
+    "/servers/{server}": {
+      "parameters": [{"$ref": "#/components/parameters/server"}],
+      "get": {
+        "operationId": "getServer",
+        "summary": "A server's details",
+        "responses": {
+          "200": {"description": "The server", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/ServerDetail"}}}},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"description": "wobbotfet isn't in the server"}
+        }
+      }
+    },
+    "/servers/{server}/roles": {
+      "parameters": [{"$ref": "#/components/parameters/server"}],
+      "get": {
+        "operationId": "getRoles",
+        "summary": "A server's roles",
+        "responses": {
+          "200": {"description": "The roles", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Role"}}}}},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"}
+        }
+      }
+    },
+    "/servers/{server}/config": {
+      "parameters": [{"$ref": "#/components/parameters/server"}],
+      "get": {
+        "operationId": "getConfig",
+        "summary": "A server's configuration",
+        "responses": {
+          "200": {"description": "The configuration", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/GuildConfig"}}}},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"}
+        }
+      },
+      "put": {
+        "operationId": "putConfig",
+        "summary": "Change a server's configuration",
+        "description": "Settings missing from the body are left as they are.",
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/GuildConfig"}}}},
+        "responses": {
+          "200": {"description": "The new configuration", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/GuildConfig"}}}},
+          "400": {"description": "A setting isn't valid"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"}
+        }
+      }
+    },
+    "/servers/{server}/wants": {
+      "parameters": [{"$ref": "#/components/parameters/server"}],
+      "get": {
+        "operationId": "getWants",
+        "summary": "A server's want roles, most wanted first",
+        "responses": {
+          "200": {"description": "The want roles", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/WantRole"}}}}},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotConfigured"}
+        }
+      }
+    },
+    "/servers/{server}/players": {
+      "parameters": [{"$ref": "#/components/parameters/server"}],
+      "get": {
+        "operationId": "getPlayers",
+        "summary": "A server's PVP players",
+        "responses": {
+          "200": {"description": "The players", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Player"}}}}},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotConfigured"}
+        }
+      }
+    },
+    "/servers/{server}/friends": {
+      "parameters": [{"$ref": "#/components/parameters/server"}],
+      "get": {
+        "operationId": "getFriends",
+        "summary": "A server's PVP players and the ultra friendships between them",
+        "responses": {
+          "200": {"description": "The graph", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/FriendGraph"}}}},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotConfigured"}
+        }
+      }
+    },
+    "/servers/{server}/battling": {
+      "parameters": [{"$ref": "#/components/parameters/server"}],
+      "get": {
+        "operationId": "getBattling",
+        "summary": "The IDs of the users looking for PVP battles on a server",
+        "responses": {
+          "200": {"description": "The users", "content": {"application/json": {"schema": {"type": "array", "items": {"type": "string"}}}}},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"$ref": "#/components/responses/NotConfigured"}
+        }
+      }
+    },
+    "/servers/{server}/usage": {
+      "parameters": [{"$ref": "#/components/parameters/server"}],
+      "get": {
+        "operationId": "getServerUsage",
+        "summary": "How a server has used each command",
+        "parameters": [{"$ref": "#/components/parameters/since"}],
+        "responses": {
+          "200": {"description": "The usage", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Usage"}}}},
+          "400": {"description": "since isn't a duration or a time"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"}
+        }
+      }
+    },
+    "/usage": {
+      "get": {
+        "operationId": "getUsage",
+        "summary": "How every server has used each command (owner only)",
+        "parameters": [{"$ref": "#/components/parameters/since"}],
+        "responses": {
+          "200": {"description": "The usage", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Usage"}}}},
+          "400": {"description": "since isn't a duration or a time"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"}
+        }
+      }
+    },
+    "/events": {
+      "get": {
+        "operationId": "getEvents",
+        "summary": "A live stream of what's happening",
+        "description": "Server-sent events named for their type, with a StreamEvent as the data. Without guild it's everywhere, which only the owner can see.",
+        "parameters": [
+          {"name": "guild", "in": "query", "description": "Only what happens in this server", "schema": {"type": "string"}}
+        ],
+        "responses": {
+          "200": {"description": "The stream", "content": {"text/event-stream": {"schema": {"$ref": "#/components/schemas/StreamEvent"}}}},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"}
+        }
+      }
+    },
+    "/channels/{channel}/messages": {
+      "parameters": [{"name": "channel", "in": "path", "required": true, "schema": {"type": "string"}}],
+      "post": {
+        "operationId": "postMessage",
+        "summary": "Post a message in a channel of a server you manage",
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Message"}}}},
+        "responses": {
+          "201": {"description": "The message", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/SentMessage"}}}},
+          "400": {"description": "The message is empty or too long"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "404": {"description": "There's no such channel"},
+          "502": {"$ref": "#/components/responses/DiscordError"}
+        }
+      }
+    },
+    "/users/{user}/messages": {
+      "parameters": [{"name": "user", "in": "path", "required": true, "schema": {"type": "string"}}],
+      "post": {
+        "operationId": "postDM",
+        "summary": "Send a user a PM (owner only)",
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Message"}}}},
+        "responses": {
+          "201": {"description": "The message", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/SentMessage"}}}},
+          "400": {"description": "The message is empty or too long"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "502": {"$ref": "#/components/responses/DiscordError"}
+        }
+      }
+    },
+    "/broadcast": {
+      "post": {
+        "operationId": "postBroadcast",
+        "summary": "Post an announcement in every server's announce channel (owner only)",
+        "description": "Servers without an announce channel, or that have turned announcements off, are skipped.",
+        "parameters": [
+          {"name": "dry_run", "in": "query", "description": "Don't post anything, just say where it would go", "schema": {"type": "boolean"}}
+        ],
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Message"}}}},
+        "responses": {
+          "200": {"description": "What happened in each server", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/BroadcastResult"}}}}},
+          "400": {"description": "The message is empty or too long"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"}
+        }
+      }
+    },
+    "/pms": {
+      "get": {
+        "operationId": "getActivePMs",
+        "summary": "The PM channels wobbotfet is waiting on a reply in (owner only)",
+        "responses": {
+          "200": {"description": "The channel IDs", "content": {"application/json": {"schema": {"type": "array", "items": {"type": "string"}}}}},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"}
+        }
+      }
+    },
+    "/errors": {
+      "get": {
+        "operationId": "getErrors",
+        "summary": "The most recent panics and errors, newest first (owner only)",
+        "responses": {
+          "200": {"description": "The errors", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Error"}}}}},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"}
+        }
+      }
+    },
+    "/reload": {
+      "post": {
+        "operationId": "reload",
+        "summary": "Reload the config (owner only)",
+        "responses": {
+          "200": {"description": "What's disabled by the new config", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/ReloadResponse"}}}},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"},
+          "500": {"description": "The config couldn't be loaded"}
+        }
+      }
+    },
+    "/metrics": {
+      "get": {
+        "operationId": "getMetrics",
+        "summary": "Prometheus metrics (owner only)",
+        "responses": {
+          "200": {"description": "The metrics", "content": {"text/plain": {}}},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Forbidden"}
+        }
+      }
+    }
+  },
+  "components": {
+    "securitySchemes": {
+      "bearer": {"type": "http", "scheme": "bearer", "description": "The API token, or a session ID"},
+      "session": {"type": "apiKey", "in": "cookie", "name": "wob_session"}
+    },
+    "parameters": {
+      "server": {"name": "server", "in": "path", "required": true, "description": "The server's ID", "schema": {"type": "string"}},
+      "since": {"name": "since", "in": "query", "description": "A duration (ie 168h) or an RFC 3339 time. Defaults to 24h.", "schema": {"type": "string"}}
+    },
+    "responses": {
+      "Unauthorized": {"description": "You aren't logged in"},
+      "Forbidden": {"description": "You can't see or do that"},
+      "NotConfigured": {"description": "The service this needs isn't configured"},
+      "DiscordError": {"description": "Discord wouldn't do it"}
+    },
+    "schemas": {
+      "Principal": {
+        "type": "object",
+        "properties": {
+          "user": {"type": "string", "description": "Your Discord user ID. It's empty for the API token."},
+          "owner": {"type": "boolean"}
+        }
+      },
+      "Server": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "string"},
+          "name": {"type": "string"},
+          "icon": {"type": "string"},
+          "owner": {"type": "boolean", "description": "Whether wobbotfet owns the server"},
+          "permissions": {"type": "integer", "description": "wobbotfet's permissions in the server, as a bit set"},
+          "parsed_permissions": {"type": "array", "items": {"type": "string"}, "description": "wobbotfet's permissions in the server, ie manage roles"},
+          "member_count": {"type": "integer"},
+          "joined_at": {"type": "string", "format": "date-time", "description": "When wobbotfet joined"}
+        }
+      },
+      "ServerDetail": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "string"},
+          "name": {"type": "string"},
+          "icon": {"type": "string"},
+          "owner": {"type": "boolean", "description": "Whether wobbotfet owns the server"},
+          "permissions": {"type": "integer", "description": "wobbotfet's permissions in the server, as a bit set"},
+          "parsed_permissions": {"type": "array", "items": {"type": "string"}, "description": "wobbotfet's permissions in the server, ie manage roles"},
+          "member_count": {"type": "integer"},
+          "joined_at": {"type": "string", "format": "date-time", "description": "When wobbotfet joined"},
+          "owner_id": {"type": "string", "description": "The ID of the server's owner"},
+          "channels": {"type": "integer"},
+          "roles": {"type": "integer"},
+          "large": {"type": "boolean"}
+        }
+      },
+      "Role": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "string"},
+          "name": {"type": "string"},
+          "managed": {"type": "boolean"},
+          "mentionable": {"type": "boolean"},
+          "hoist": {"type": "boolean"},
+          "color": {"type": "integer"},
+          "position": {"type": "integer"},
+          "permissions": {"type": "integer"}
+        }
+      },
+      "GuildConfig": {
+        "type": "object",
+        "properties": {
+          "disabled_commands": {"type": "array", "items": {"type": "string"}},
+          "default_league": {"type": "string", "enum": ["great", "ultra"]},
+          "create_roles": {"type": "boolean"},
+          "role_prefix": {"type": "string"},
+          "pvp_channel": {"type": "string"},
+          "language": {"type": "string", "enum": ["en"]},
+          "prefix": {"type": "string", "maxLength": 10},
+          "legacy_aliases": {"type": "boolean"},
+          "announce_channel": {"type": "string"},
+          "announcements": {"type": "boolean"},
+          "unknown_commands": {"type": "boolean"}
+        }
+      },
+      "WantRole": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "string"},
+          "name": {"type": "string"},
+          "pokemon": {"type": "string"},
+          "members": {"type": "integer"}
+        }
+      },
+      "Player": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "string"},
+          "username": {"type": "string"},
+          "ign": {"type": "string"},
+          "egg_ultra": {"type": "boolean"}
+        }
+      },
+      "Friendship": {
+        "type": "object",
+        "properties": {
+          "user": {"type": "string"},
+          "friend": {"type": "string"}
+        }
+      },
+      "FriendGraph": {
+        "type": "object",
+        "properties": {
+          "players": {"type": "array", "items": {"$ref": "#/components/schemas/Player"}},
+          "friendships": {"type": "array", "items": {"$ref": "#/components/schemas/Friendship"}}
+        }
+      },
+      "Usage": {
+        "type": "object",
+        "properties": {
+          "since": {"type": "string", "format": "date-time"},
+          "until": {"type": "string", "format": "date-time"},
+          "total": {"type": "integer"},
+          "users": {"type": "integer"},
+          "commands": {"type": "object", "additionalProperties": {"$ref": "#/components/schemas/CommandUsage"}}
+        }
+      },
+      "CommandUsage": {
+        "type": "object",
+        "properties": {
+          "count": {"type": "integer"},
+          "users": {"type": "integer"},
+          "outcomes": {"type": "object", "additionalProperties": {"type": "integer"}},
+          "avg_latency_ms": {"type": "number"}
+        }
+      },
+      "StreamEvent": {
+        "type": "object",
+        "properties": {
+          "type": {"type": "string", "enum": ["command", "backend_error", "panic", "conversation"]},
+          "time": {"type": "string", "format": "date-time"},
+          "guild": {"type": "string"},
+          "data": {"type": "object", "description": "Depends on the type"}
+        }
+      },
+      "Error": {
+        "type": "object",
+        "description": "Something that went wrong, and what was being done when it did",
+        "properties": {
+          "kind": {"type": "string", "enum": ["panic", "error"]},
+          "time": {"type": "string", "format": "date-time"},
+          "signature": {"type": "string", "description": "The same for errors that are the same problem"},
+          "message": {"type": "string"},
+          "stack": {"type": "string", "description": "Only for panics"},
+          "command": {"type": "string"},
+          "guild": {"type": "string", "description": "Empty for errors in a PM, or that didn't happen while handling a command"},
+          "channel": {"type": "string"},
+          "user": {"type": "string"}
+        }
+      },
+      "Message": {
+        "type": "object",
+        "properties": {
+          "content": {"type": "string", "maxLength": 2000},
+          "embed": {"type": "object", "description": "A Discord embed object"}
+        }
+      },
+      "SentMessage": {
+        "type": "object",
+        "description": "The Discord message object, of which these are the useful bits",
+        "properties": {
+          "id": {"type": "string"},
+          "channel_id": {"type": "string"},
+          "guild_id": {"type": "string"},
+          "content": {"type": "string"}
+        }
+      },
+      "BroadcastResult": {
+        "type": "object",
+        "properties": {
+          "guild": {"type": "string"},
+          "channel": {"type": "string"},
+          "sent": {"type": "boolean"},
+          "skipped": {"type": "string", "description": "Why it wasn't sent, if the server doesn't want it"},
+          "error": {"type": "string"}
+        }
+      },
+      "ReloadResponse": {
+        "type": "object",
+        "properties": {
+          "disabled": {"type": "array", "items": {"type": "string"}}
+        }
+      }
+    }
+  }
+}
+`
@@ -0,0 +1,15 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestCheckSpec(t *testing.T) {
+	r := mux.NewRouter()
+	(&API{}).Routes(r)
+	if err := CheckSpec(r); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// A Player is a PVP player. Friend codes are left out: they're shared between players, not with admins.
+type Player struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	IGN      string `json:"ign"`
+	EggUltra bool   `json:"egg_ultra"`
+}
+
+// A FriendGraph is a server's PVP players and the ultra friendships between them.
+type FriendGraph struct {
+	Players     []Player     `json:"players"`
+	Friendships []Friendship `json:"friendships"`
+}
+
+// A Friendship is between two players, by ID. Each pair is only listed once.
+type Friendship struct {
+	User   string `json:"user"`
+	Friend string `json:"friend"`
+}
+
+// GetPlayers returns a server's PVP players.
+func (a *API) GetPlayers(w http.ResponseWriter, r *http.Request) {
+	players, err := a.players(mux.Vars(r)["server"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, players)
+}
+
+// GetFriends returns the graph of ultra friendships between a server's PVP players.
+func (a *API) GetFriends(w http.ResponseWriter, r *http.Request) {
+	server := mux.Vars(r)["server"]
+	players, err := a.players(server)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	friendships, err := a.bot.Friendships(server)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	graph := &FriendGraph{
+		Players:     players,
+		Friendships: make([]Friendship, len(friendships)),
+	}
+	for i, f := range friendships {
+		graph.Friendships[i] = Friendship{User: f.User, Friend: f.Friend}
+	}
+	writeJSON(w, graph)
+}
+
+// GetBattling returns the IDs of the users looking for PVP battles on a server.
+func (a *API) GetBattling(w http.ResponseWriter, r *http.Request) {
+	users, err := a.bot.BattlingUsers(mux.Vars(r)["server"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, users)
+}
+
+func (a *API) players(server string) ([]Player, error) {
+	p, err := a.bot.Players(server)
+	if err != nil {
+		return nil, err
+	}
+
+	players := make([]Player, len(p))
+	for i, player := range p {
+		players[i] = Player{
+			ID:       player.ID,
+			Username: player.Username,
+			IGN:      player.IGN,
+			EggUltra: player.EggUltra,
+		}
+	}
+	return players, nil
+}
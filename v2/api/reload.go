@@ -0,0 +1,33 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// A ReloadResponse lists what's disabled by the reloaded config.
+type ReloadResponse struct {
+	Disabled []string `json:"disabled"`
+}
+
+// Reload makes the bot reload its config.
+func (a *API) Reload(w http.ResponseWriter, r *http.Request) {
+	report, err := a.bot.Reload()
+	if err != nil {
+		log.Printf("error reloading config: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if report == nil {
+		report = []string{}
+	}
+
+	b, err := json.Marshal(&ReloadResponse{Disabled: report})
+	if err != nil {
+		log.Printf("error marshalling json: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Write(b)
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/Sigafoos/wobbotfet/dashboard"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Routes registers the dashboard API (and the dashboard) on r. Every route has to be in the OpenAPI spec, which
+// CheckSpec checks.
+func (a *API) Routes(r *mux.Router) {
+	// the page itself is public; everything it shows comes from the API
+	r.HandleFunc("/", dashboard.Index).Methods(http.MethodGet)
+	r.HandleFunc("/openapi.json", a.GetOpenAPI).Methods(http.MethodGet)
+
+	r.HandleFunc("/auth/login", a.Login).Methods(http.MethodGet)
+	r.HandleFunc("/auth/callback", a.Callback).Methods(http.MethodGet)
+	r.HandleFunc("/auth/logout", a.Logout).Methods(http.MethodPost)
+	r.HandleFunc("/auth/me", a.Authenticated(a.Me)).Methods(http.MethodGet)
+
+	r.HandleFunc("/servers", a.Authenticated(a.GetServers)).Methods(http.MethodGet)
+	r.HandleFunc("/pms", a.Owner(a.GetActivePMs)).Methods(http.MethodGet)
+	r.HandleFunc("/usage", a.Owner(a.GetUsage)).Methods(http.MethodGet)
+	r.HandleFunc("/errors", a.Owner(a.GetErrors)).Methods(http.MethodGet)
+	r.HandleFunc("/events", a.Authenticated(a.GetEvents)).Methods(http.MethodGet)
+	r.HandleFunc("/channels/{channel}/messages", a.Authenticated(a.PostMessage)).Methods(http.MethodPost)
+	r.HandleFunc("/users/{user}/messages", a.Owner(a.PostDM)).Methods(http.MethodPost)
+	r.HandleFunc("/broadcast", a.Owner(a.PostBroadcast)).Methods(http.MethodPost)
+	r.HandleFunc("/servers/{server}", a.GuildAdmin(a.GetServer)).Methods(http.MethodGet)
+	r.HandleFunc("/servers/{server}/roles", a.GuildAdmin(a.GetRoles)).Methods(http.MethodGet)
+	r.HandleFunc("/servers/{server}/config", a.GuildAdmin(a.GetConfig)).Methods(http.MethodGet)
+	r.HandleFunc("/servers/{server}/config", a.GuildAdmin(a.PutConfig)).Methods(http.MethodPut)
+	r.HandleFunc("/servers/{server}/wants", a.GuildAdmin(a.GetWants)).Methods(http.MethodGet)
+	r.HandleFunc("/servers/{server}/players", a.GuildAdmin(a.GetPlayers)).Methods(http.MethodGet)
+	r.HandleFunc("/servers/{server}/friends", a.GuildAdmin(a.GetFriends)).Methods(http.MethodGet)
+	r.HandleFunc("/servers/{server}/battling", a.GuildAdmin(a.GetBattling)).Methods(http.MethodGet)
+	r.HandleFunc("/servers/{server}/usage", a.GuildAdmin(a.GetUsage)).Methods(http.MethodGet)
+	r.HandleFunc("/reload", a.Owner(a.Reload)).Methods(http.MethodPost)
+	r.HandleFunc("/metrics", a.Owner(promhttp.Handler().ServeHTTP)).Methods(http.MethodGet)
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Sigafoos/wobbotfet/bot"
+	"github.com/gorilla/mux"
+)
+
+// PostMessage posts a message to a channel. Anyone but the owner can only post in the servers they manage.
+func (a *API) PostMessage(w http.ResponseWriter, r *http.Request) {
+	channel := mux.Vars(r)["channel"]
+	guild, err := a.bot.ChannelGuild(channel)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	p := principal(r)
+	if !p.Owner && (guild == "" || !a.canManage(p, guild)) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	m, ok := readMessage(w, r)
+	if !ok {
+		return
+	}
+	sent, err := a.bot.Send(channel, m)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSONStatus(w, http.StatusCreated, sent)
+}
+
+// PostDM sends a message to a user in a PM.
+func (a *API) PostDM(w http.ResponseWriter, r *http.Request) {
+	m, ok := readMessage(w, r)
+	if !ok {
+		return
+	}
+	sent, err := a.bot.DM(mux.Vars(r)["user"], m)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSONStatus(w, http.StatusCreated, sent)
+}
+
+// PostBroadcast posts an announcement to every server's announce channel, skipping those that opted out. With
+// `?dry_run=true` nothing's posted, but the response says where it would have been.
+func (a *API) PostBroadcast(w http.ResponseWriter, r *http.Request) {
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	m, ok := readMessage(w, r)
+	if !ok {
+		return
+	}
+	results, err := a.bot.Broadcast(m, dryRun)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, results)
+}
+
+// readMessage decodes the message in the request, responding with a 400 if it's not something that can be sent.
+func readMessage(w http.ResponseWriter, r *http.Request) (*bot.Message, bool) {
+	var m bot.Message
+	if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	if err := m.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	return &m, true
+}
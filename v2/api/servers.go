@@ -2,19 +2,39 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/gorilla/mux"
 )
 
 // A Server represents a Discord guild/server.
 type Server struct {
 	*discordgo.UserGuild
 	ParsedPermissions []string `json:"parsed_permissions"`
+	// MemberCount and JoinedAt are only there if the server's in the gateway's state.
+	MemberCount int        `json:"member_count,omitempty"`
+	JoinedAt    *time.Time `json:"joined_at,omitempty"`
 }
 
-// GetServers returns a list of servers the bot is connected to.
+// A ServerDetail is everything about a server.
+type ServerDetail struct {
+	*Server
+	OwnerID  string `json:"owner_id"`
+	Channels int    `json:"channels"`
+	Roles    int    `json:"roles"`
+	Large    bool   `json:"large"`
+}
+
+// GetServers returns a list of servers the bot is connected to. Anyone but the owner only sees the servers they
+// can manage. `name` filters them to names containing it and `permission` to those the bot has a (parsed)
+// permission in. `sort` is `name` (the default), `members` (biggest first) or `joined` (newest first), and
+// `order=desc` reverses it.
 func (a *API) GetServers(w http.ResponseWriter, r *http.Request) {
 	s, err := a.bot.Servers()
 	if err != nil {
@@ -23,12 +43,48 @@ func (a *API) GetServers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var servers []*Server
+	query := r.URL.Query()
+	name := strings.ToLower(query.Get("name"))
+	permission := strings.ToLower(query.Get("permission"))
+
+	p := principal(r)
+	servers := []*Server{}
 	for _, v := range s {
-		servers = append(servers, &Server{
-			v,
-			parsePermissions(v.Permissions),
+		if !a.canManage(p, v.ID) {
+			continue
+		}
+		if name != "" && !strings.Contains(strings.ToLower(v.Name), name) {
+			continue
+		}
+		server := a.server(v)
+		if permission != "" && !hasPermission(server.ParsedPermissions, permission) {
+			continue
+		}
+		servers = append(servers, server)
+	}
+
+	switch query.Get("sort") {
+	case "", "name":
+		// they come sorted by name
+	case "members":
+		sort.SliceStable(servers, func(i, j int) bool {
+			return servers[i].MemberCount > servers[j].MemberCount
 		})
+	case "joined":
+		sort.SliceStable(servers, func(i, j int) bool {
+			if servers[i].JoinedAt == nil || servers[j].JoinedAt == nil {
+				return servers[j].JoinedAt == nil && servers[i].JoinedAt != nil
+			}
+			return servers[i].JoinedAt.After(*servers[j].JoinedAt)
+		})
+	default:
+		http.Error(w, "sort should be name, members or joined", http.StatusBadRequest)
+		return
+	}
+	if query.Get("order") == "desc" {
+		for i, j := 0, len(servers)-1; i < j; i, j = i+1, j-1 {
+			servers[i], servers[j] = servers[j], servers[i]
+		}
 	}
 
 	b, err := json.Marshal(servers)
@@ -40,6 +96,61 @@ func (a *API) GetServers(w http.ResponseWriter, r *http.Request) {
 	w.Write(b)
 }
 
+// GetServer returns the details of a server.
+func (a *API) GetServer(w http.ResponseWriter, r *http.Request) {
+	guild, err := a.bot.Server(mux.Vars(r)["server"])
+	if errors.Is(err, discordgo.ErrStateNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	} else if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	server := a.server(&discordgo.UserGuild{ID: guild.ID, Name: guild.Name, Icon: guild.Icon})
+	// the server list has the bot's permissions
+	if s, err := a.bot.Servers(); err == nil {
+		for _, v := range s {
+			if v.ID == guild.ID {
+				server = a.server(v)
+				break
+			}
+		}
+	}
+
+	writeJSON(w, &ServerDetail{
+		Server:   server,
+		OwnerID:  guild.OwnerID,
+		Channels: len(guild.Channels),
+		Roles:    len(guild.Roles),
+		Large:    guild.Large,
+	})
+}
+
+// server fills in what's known about a server from the gateway's state.
+func (a *API) server(ug *discordgo.UserGuild) *Server {
+	server := &Server{
+		UserGuild:         ug,
+		ParsedPermissions: parsePermissions(ug.Permissions),
+	}
+	if guild, err := a.bot.Server(ug.ID); err == nil {
+		server.MemberCount = guild.MemberCount
+		if joined, err := guild.JoinedAt.Parse(); err == nil {
+			server.JoinedAt = &joined
+		}
+	}
+	return server
+}
+
+func hasPermission(permissions []string, permission string) bool {
+	for _, p := range permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
 func parsePermissions(p int) []string {
 	permissions := []string{}
 	if p&discordgo.PermissionSendMessages > 0 {
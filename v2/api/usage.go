@@ -0,0 +1,38 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// defaultUsageWindow is how far back usage goes if `since` isn't given.
+const defaultUsageWindow = 24 * time.Hour
+
+// GetUsage returns command usage since `since`, which is either a duration (ie `168h` for a week) or an RFC
+// 3339 time. It defaults to the last day. With a {server} it's only that server's usage; without, it's everything.
+func (a *API) GetUsage(w http.ResponseWriter, r *http.Request) {
+	since, err := parseSince(r.URL.Query().Get("since"))
+	if err != nil {
+		http.Error(w, "since should be a duration (ie 24h) or an RFC 3339 time", http.StatusBadRequest)
+		return
+	}
+
+	usage, err := a.bot.Usage(mux.Vars(r)["server"], since)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, usage)
+}
+
+func parseSince(since string) (time.Time, error) {
+	if since == "" {
+		return time.Now().Add(-defaultUsageWindow), nil
+	}
+	if d, err := time.ParseDuration(since); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, since)
+}
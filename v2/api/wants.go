@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// A WantRole is a role for people who want a Pokemon.
+type WantRole struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Pokemon string `json:"pokemon"`
+	Members int    `json:"members"`
+}
+
+// GetWants returns a server's want roles and how many members have each, most wanted first.
+func (a *API) GetWants(w http.ResponseWriter, r *http.Request) {
+	roles, err := a.bot.WantRoles(mux.Vars(r)["server"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	wants := make([]WantRole, len(roles))
+	for i, role := range roles {
+		wants[i] = WantRole{
+			ID:      role.Role.ID,
+			Name:    role.Role.Name,
+			Pokemon: role.Pokemon,
+			Members: role.Members,
+		}
+	}
+	writeJSON(w, wants)
+}
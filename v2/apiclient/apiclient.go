@@ -0,0 +1,114 @@
+// Package apiclient is a client for the dashboard API. The types and methods in generated.go are generated from the
+// API's OpenAPI spec by cmd/apigen, so after changing the spec run go generate here.
+package apiclient
+
+//go:generate go run ../cmd/apigen -o generated.go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// A Client talks to the dashboard API.
+type Client struct {
+	base  string
+	token string
+	http  *http.Client
+}
+
+// An Option configures a Client.
+type Option func(*Client)
+
+// Token authenticates with an API token or a session ID.
+func Token(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// HTTPClient makes requests with h instead of http.DefaultClient.
+func HTTPClient(h *http.Client) Option {
+	return func(c *Client) {
+		c.http = h
+	}
+}
+
+// New returns a client for the API at base, eg "http://localhost:8081".
+func New(base string, opts ...Option) *Client {
+	c := &Client{
+		base: strings.TrimSuffix(base, "/"),
+		http: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// A StatusError is a response that wasn't what the spec said it would be.
+type StatusError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("%s %s: %d %s", e.Method, e.Path, e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("%s %s: %d %s: %s", e.Method, e.Path, e.StatusCode, http.StatusText(e.StatusCode), e.Body)
+}
+
+// do makes a request, encoding in as the body if it's not nil and decoding the response into out if it's not nil. Any
+// status but want is a *StatusError.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out interface{}, want int) error {
+	u := c.base + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, u, &body)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != want {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return &StatusError{
+			Method:     method,
+			Path:       path,
+			StatusCode: resp.StatusCode,
+			Body:       strings.TrimSpace(string(b)),
+		}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,444 @@
+// Code generated by apigen from the dashboard API's OpenAPI spec. DO NOT EDIT.
+
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// BroadcastResult is the BroadcastResult schema.
+type BroadcastResult struct {
+	Guild   string `json:"guild"`
+	Channel string `json:"channel"`
+	Sent    bool   `json:"sent"`
+	// Why it wasn't sent, if the server doesn't want it
+	Skipped string `json:"skipped"`
+	Error   string `json:"error"`
+}
+
+// CommandUsage is the CommandUsage schema.
+type CommandUsage struct {
+	Count        int            `json:"count"`
+	Users        int            `json:"users"`
+	Outcomes     map[string]int `json:"outcomes"`
+	AvgLatencyMS float64        `json:"avg_latency_ms"`
+}
+
+// Error is something that went wrong, and what was being done when it did.
+type Error struct {
+	Kind string    `json:"kind"`
+	Time time.Time `json:"time"`
+	// The same for errors that are the same problem
+	Signature string `json:"signature"`
+	Message   string `json:"message"`
+	// Only for panics
+	Stack   string `json:"stack"`
+	Command string `json:"command"`
+	// Empty for errors in a PM, or that didn't happen while handling a command
+	Guild   string `json:"guild"`
+	Channel string `json:"channel"`
+	User    string `json:"user"`
+}
+
+// FriendGraph is the FriendGraph schema.
+type FriendGraph struct {
+	Players     []Player     `json:"players"`
+	Friendships []Friendship `json:"friendships"`
+}
+
+// Friendship is the Friendship schema.
+type Friendship struct {
+	User   string `json:"user"`
+	Friend string `json:"friend"`
+}
+
+// GuildConfig is the GuildConfig schema.
+type GuildConfig struct {
+	DisabledCommands []string `json:"disabled_commands"`
+	DefaultLeague    string   `json:"default_league"`
+	CreateRoles      bool     `json:"create_roles"`
+	RolePrefix       string   `json:"role_prefix"`
+	PVPChannel       string   `json:"pvp_channel"`
+	Language         string   `json:"language"`
+	Prefix           string   `json:"prefix"`
+	LegacyAliases    bool     `json:"legacy_aliases"`
+	AnnounceChannel  string   `json:"announce_channel"`
+	Announcements    bool     `json:"announcements"`
+	UnknownCommands  bool     `json:"unknown_commands"`
+}
+
+// Message is the Message schema.
+type Message struct {
+	Content string `json:"content"`
+	// A Discord embed object
+	Embed json.RawMessage `json:"embed"`
+}
+
+// Player is the Player schema.
+type Player struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	IGN      string `json:"ign"`
+	EggUltra bool   `json:"egg_ultra"`
+}
+
+// Principal is the Principal schema.
+type Principal struct {
+	// Your Discord user ID. It's empty for the API token.
+	User  string `json:"user"`
+	Owner bool   `json:"owner"`
+}
+
+// ReloadResponse is the ReloadResponse schema.
+type ReloadResponse struct {
+	Disabled []string `json:"disabled"`
+}
+
+// Role is the Role schema.
+type Role struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Managed     bool   `json:"managed"`
+	Mentionable bool   `json:"mentionable"`
+	Hoist       bool   `json:"hoist"`
+	Color       int    `json:"color"`
+	Position    int    `json:"position"`
+	Permissions int    `json:"permissions"`
+}
+
+// SentMessage is the Discord message object, of which these are the useful bits.
+type SentMessage struct {
+	ID        string `json:"id"`
+	ChannelID string `json:"channel_id"`
+	GuildID   string `json:"guild_id"`
+	Content   string `json:"content"`
+}
+
+// Server is the Server schema.
+type Server struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Icon string `json:"icon"`
+	// Whether wobbotfet owns the server
+	Owner bool `json:"owner"`
+	// wobbotfet's permissions in the server, as a bit set
+	Permissions int `json:"permissions"`
+	// wobbotfet's permissions in the server, ie manage roles
+	ParsedPermissions []string `json:"parsed_permissions"`
+	MemberCount       int      `json:"member_count"`
+	// When wobbotfet joined
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// ServerDetail is the ServerDetail schema.
+type ServerDetail struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Icon string `json:"icon"`
+	// Whether wobbotfet owns the server
+	Owner bool `json:"owner"`
+	// wobbotfet's permissions in the server, as a bit set
+	Permissions int `json:"permissions"`
+	// wobbotfet's permissions in the server, ie manage roles
+	ParsedPermissions []string `json:"parsed_permissions"`
+	MemberCount       int      `json:"member_count"`
+	// When wobbotfet joined
+	JoinedAt time.Time `json:"joined_at"`
+	// The ID of the server's owner
+	OwnerID  string `json:"owner_id"`
+	Channels int    `json:"channels"`
+	Roles    int    `json:"roles"`
+	Large    bool   `json:"large"`
+}
+
+// StreamEvent is the StreamEvent schema.
+type StreamEvent struct {
+	Type  string    `json:"type"`
+	Time  time.Time `json:"time"`
+	Guild string    `json:"guild"`
+	// Depends on the type
+	Data json.RawMessage `json:"data"`
+}
+
+// Usage is the Usage schema.
+type Usage struct {
+	Since    time.Time               `json:"since"`
+	Until    time.Time               `json:"until"`
+	Total    int                     `json:"total"`
+	Users    int                     `json:"users"`
+	Commands map[string]CommandUsage `json:"commands"`
+}
+
+// WantRole is the WantRole schema.
+type WantRole struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Pokemon string `json:"pokemon"`
+	Members int    `json:"members"`
+}
+
+// Logout calls POST /auth/logout: end the session.
+func (c *Client) Logout(ctx context.Context) error {
+	var query url.Values
+	return c.do(ctx, http.MethodPost, "/auth/logout", query, nil, nil, 204)
+}
+
+// GetMe calls GET /auth/me: who you're logged in as.
+func (c *Client) GetMe(ctx context.Context) (*Principal, error) {
+	var query url.Values
+	var out Principal
+	if err := c.do(ctx, http.MethodGet, "/auth/me", query, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// PostBroadcastParams are the optional query parameters for PostBroadcast.
+type PostBroadcastParams struct {
+	// Don't post anything, just say where it would go
+	DryRun bool
+}
+
+// PostBroadcast calls POST /broadcast: post an announcement in every server's announce channel (owner only).
+func (c *Client) PostBroadcast(ctx context.Context, params *PostBroadcastParams, body *Message) ([]BroadcastResult, error) {
+	query := url.Values{}
+	if params != nil {
+		if params.DryRun {
+			query.Set("dry_run", "true")
+		}
+	}
+	var out []BroadcastResult
+	if err := c.do(ctx, http.MethodPost, "/broadcast", query, body, &out, 200); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PostMessage calls POST /channels/{channel}/messages: post a message in a channel of a server you manage.
+func (c *Client) PostMessage(ctx context.Context, channel string, body *Message) (*SentMessage, error) {
+	var query url.Values
+	var out SentMessage
+	if err := c.do(ctx, http.MethodPost, "/channels/"+url.PathEscape(channel)+"/messages", query, body, &out, 201); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetErrors calls GET /errors: the most recent panics and errors, newest first (owner only).
+func (c *Client) GetErrors(ctx context.Context) ([]Error, error) {
+	var query url.Values
+	var out []Error
+	if err := c.do(ctx, http.MethodGet, "/errors", query, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetOpenAPI calls GET /openapi.json: this document.
+func (c *Client) GetOpenAPI(ctx context.Context) (json.RawMessage, error) {
+	var query url.Values
+	var out json.RawMessage
+	if err := c.do(ctx, http.MethodGet, "/openapi.json", query, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetActivePMs calls GET /pms: the PM channels wobbotfet is waiting on a reply in (owner only).
+func (c *Client) GetActivePMs(ctx context.Context) ([]string, error) {
+	var query url.Values
+	var out []string
+	if err := c.do(ctx, http.MethodGet, "/pms", query, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Reload calls POST /reload: reload the config (owner only).
+func (c *Client) Reload(ctx context.Context) (*ReloadResponse, error) {
+	var query url.Values
+	var out ReloadResponse
+	if err := c.do(ctx, http.MethodPost, "/reload", query, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetServersParams are the optional query parameters for GetServers.
+type GetServersParams struct {
+	// Only servers with names containing this
+	Name string
+	// Only servers wobbotfet has this (parsed) permission in
+	Permission string
+	Sort       string
+	// desc reverses the sort
+	Order string
+}
+
+// GetServers calls GET /servers: the servers wobbotfet is in that you can manage.
+func (c *Client) GetServers(ctx context.Context, params *GetServersParams) ([]Server, error) {
+	query := url.Values{}
+	if params != nil {
+		if params.Name != "" {
+			query.Set("name", params.Name)
+		}
+		if params.Permission != "" {
+			query.Set("permission", params.Permission)
+		}
+		if params.Sort != "" {
+			query.Set("sort", params.Sort)
+		}
+		if params.Order != "" {
+			query.Set("order", params.Order)
+		}
+	}
+	var out []Server
+	if err := c.do(ctx, http.MethodGet, "/servers", query, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetServer calls GET /servers/{server}: a server's details.
+func (c *Client) GetServer(ctx context.Context, server string) (*ServerDetail, error) {
+	var query url.Values
+	var out ServerDetail
+	if err := c.do(ctx, http.MethodGet, "/servers/"+url.PathEscape(server), query, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetBattling calls GET /servers/{server}/battling: the IDs of the users looking for PVP battles on a server.
+func (c *Client) GetBattling(ctx context.Context, server string) ([]string, error) {
+	var query url.Values
+	var out []string
+	if err := c.do(ctx, http.MethodGet, "/servers/"+url.PathEscape(server)+"/battling", query, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetConfig calls GET /servers/{server}/config: a server's configuration.
+func (c *Client) GetConfig(ctx context.Context, server string) (*GuildConfig, error) {
+	var query url.Values
+	var out GuildConfig
+	if err := c.do(ctx, http.MethodGet, "/servers/"+url.PathEscape(server)+"/config", query, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// PutConfig calls PUT /servers/{server}/config: change a server's configuration.
+func (c *Client) PutConfig(ctx context.Context, server string, body *GuildConfig) (*GuildConfig, error) {
+	var query url.Values
+	var out GuildConfig
+	if err := c.do(ctx, http.MethodPut, "/servers/"+url.PathEscape(server)+"/config", query, body, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetFriends calls GET /servers/{server}/friends: a server's PVP players and the ultra friendships between them.
+func (c *Client) GetFriends(ctx context.Context, server string) (*FriendGraph, error) {
+	var query url.Values
+	var out FriendGraph
+	if err := c.do(ctx, http.MethodGet, "/servers/"+url.PathEscape(server)+"/friends", query, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetPlayers calls GET /servers/{server}/players: a server's PVP players.
+func (c *Client) GetPlayers(ctx context.Context, server string) ([]Player, error) {
+	var query url.Values
+	var out []Player
+	if err := c.do(ctx, http.MethodGet, "/servers/"+url.PathEscape(server)+"/players", query, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetRoles calls GET /servers/{server}/roles: a server's roles.
+func (c *Client) GetRoles(ctx context.Context, server string) ([]Role, error) {
+	var query url.Values
+	var out []Role
+	if err := c.do(ctx, http.MethodGet, "/servers/"+url.PathEscape(server)+"/roles", query, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetServerUsageParams are the optional query parameters for GetServerUsage.
+type GetServerUsageParams struct {
+	// A duration (ie 168h) or an RFC 3339 time. Defaults to 24h.
+	Since string
+}
+
+// GetServerUsage calls GET /servers/{server}/usage: how a server has used each command.
+func (c *Client) GetServerUsage(ctx context.Context, server string, params *GetServerUsageParams) (*Usage, error) {
+	query := url.Values{}
+	if params != nil {
+		if params.Since != "" {
+			query.Set("since", params.Since)
+		}
+	}
+	var out Usage
+	if err := c.do(ctx, http.MethodGet, "/servers/"+url.PathEscape(server)+"/usage", query, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetWants calls GET /servers/{server}/wants: a server's want roles, most wanted first.
+func (c *Client) GetWants(ctx context.Context, server string) ([]WantRole, error) {
+	var query url.Values
+	var out []WantRole
+	if err := c.do(ctx, http.MethodGet, "/servers/"+url.PathEscape(server)+"/wants", query, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetUsageParams are the optional query parameters for GetUsage.
+type GetUsageParams struct {
+	// A duration (ie 168h) or an RFC 3339 time. Defaults to 24h.
+	Since string
+}
+
+// GetUsage calls GET /usage: how every server has used each command (owner only).
+func (c *Client) GetUsage(ctx context.Context, params *GetUsageParams) (*Usage, error) {
+	query := url.Values{}
+	if params != nil {
+		if params.Since != "" {
+			query.Set("since", params.Since)
+		}
+	}
+	var out Usage
+	if err := c.do(ctx, http.MethodGet, "/usage", query, nil, &out, 200); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// PostDM calls POST /users/{user}/messages: send a user a PM (owner only).
+func (c *Client) PostDM(ctx context.Context, user string, body *Message) (*SentMessage, error) {
+	var query url.Values
+	var out SentMessage
+	if err := c.do(ctx, http.MethodPost, "/users/"+url.PathEscape(user)+"/messages", query, body, &out, 201); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// These don't respond with JSON, so there's no method for them:
+//
+//  GET /
+//  GET /auth/callback
+//  GET /auth/login
+//  GET /events
+//  GET /metrics
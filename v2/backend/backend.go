@@ -0,0 +1,356 @@
+// Package backend holds the HTTP plumbing shared by the rank, want and pvp service clients.
+package backend
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+const applicationJSON = "application/json"
+
+// DefaultTimeout is how long a request waits for a service, including any retries, when no Timeout option is given.
+const DefaultTimeout = 10 * time.Second
+
+// RequestIDHeader is the header the request ID is sent in, so a request can be traced through the services' logs.
+const RequestIDHeader = "X-Request-ID"
+
+// Errors a service client can return. Use errors.Is to check for them, as they're wrapped with details of the request.
+var (
+	ErrNotFound  = errors.New("not found")
+	ErrConflict  = errors.New("conflict")
+	ErrForbidden = errors.New("forbidden")
+)
+
+// A StatusError is returned when a service responds with a status code the client wasn't expecting.
+type StatusError struct {
+	Service    string
+	Method     string
+	URL        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: %s %s: got %v", e.Service, e.Method, e.URL, e.StatusCode)
+}
+
+// DefaultRetries is how many times an idempotent request is retried when no Retries option is given.
+const DefaultRetries = 2
+
+// backoff is how long to wait before the first retry. It doubles with each subsequent one.
+const backoff = 200 * time.Millisecond
+
+// A Client makes JSON requests to a service.
+type Client struct {
+	name      string
+	base      string
+	http      *http.Client
+	timeout   time.Duration
+	basicuser string
+	basicpass string
+	retries   int
+	breaker   *breaker
+	observers []Observer
+}
+
+// A Result describes a finished request, for logging and metrics.
+type Result struct {
+	Service   string
+	Method    string
+	Path      string
+	RequestID string
+	// StatusCode is 0 if the service didn't respond (or wasn't asked, if the circuit breaker is open).
+	StatusCode int
+	// Err is set if the service didn't respond. Unexpected status codes aren't errors here.
+	Err      error
+	Duration time.Duration
+	Attempts int
+}
+
+// An Observer is called after every request.
+type Observer func(Result)
+
+// An Option configures a Client.
+type Option func(*Client)
+
+// Timeout sets how long a request waits for the service before giving up. It's for every attempt at the request
+// together, so retrying doesn't make the caller wait any longer.
+func Timeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = d
+	}
+}
+
+// BasicAuth sets the credentials sent with every request. Empty values are ignored.
+func BasicAuth(user, pass string) Option {
+	return func(c *Client) {
+		c.basicuser = user
+		c.basicpass = pass
+	}
+}
+
+// Retries sets how many times an idempotent (GET, HEAD, PUT or DELETE) request is retried after a network error or
+// a 5xx response. Other requests are never retried.
+func Retries(n int) Option {
+	return func(c *Client) {
+		c.retries = n
+	}
+}
+
+// Breaker sets how many consecutive failures open the circuit breaker, and how long it stays open before a request
+// is allowed through to see if the service has recovered.
+func Breaker(threshold int, cooldown time.Duration) Option {
+	return func(c *Client) {
+		c.breaker.threshold = threshold
+		c.breaker.cooldown = cooldown
+	}
+}
+
+// OnStateChange sets a function to be called (in its own goroutine) when the circuit breaker changes state.
+func OnStateChange(f StateChangeFunc) Option {
+	return func(c *Client) {
+		c.breaker.onChange = f
+	}
+}
+
+// Observe adds a function to be called after every request.
+func Observe(f Observer) Option {
+	return func(c *Client) {
+		c.observers = append(c.observers, f)
+	}
+}
+
+// HTTPClient replaces the underlying http.Client.
+func HTTPClient(h *http.Client) Option {
+	return func(c *Client) {
+		c.http = h
+	}
+}
+
+// New returns a Client for the service at base (no trailing slash). The name is used in errors and logs.
+func New(name, base string, opts ...Option) *Client {
+	c := &Client{
+		name:    name,
+		base:    base,
+		http:    &http.Client{},
+		timeout: DefaultTimeout,
+		retries: DefaultRetries,
+		breaker: &breaker{
+			name:      name,
+			threshold: DefaultFailureThreshold,
+			cooldown:  DefaultCooldown,
+		},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Name returns the name of the service.
+func (c *Client) Name() string {
+	return c.name
+}
+
+// State returns the state of the service's circuit breaker.
+func (c *Client) State() State {
+	return c.breaker.current()
+}
+
+// Do performs a request against path. If in is non-nil it's sent as the JSON body, and if out is non-nil the
+// response body is decoded into it. A status code not in expect is returned as an error: 403, 404 and 409 wrap
+// ErrForbidden, ErrNotFound and ErrConflict, anything else is a *StatusError. If expect is empty, 200 is expected.
+func (c *Client) Do(ctx context.Context, method, path string, in, out interface{}, expect ...int) error {
+	var body []byte
+	if in != nil {
+		var err error
+		body, err = json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("%s: error marshalling request: %w", c.name, err)
+		}
+	}
+
+	// every attempt should have the same ID, and share the timeout
+	ctx = WithRequestID(ctx, RequestID(ctx))
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	result := Result{
+		Service:   c.name,
+		Method:    method,
+		Path:      path,
+		RequestID: RequestID(ctx),
+	}
+	start := time.Now()
+
+	generation, allowed := c.breaker.allow()
+	if !allowed {
+		err := &UnavailableError{Service: c.name}
+		result.Err = err
+		c.observe(result)
+		return err
+	}
+
+	attempts := 1
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		attempts += c.retries
+	}
+
+	var resp *http.Response
+	var err error
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				resp, err = nil, ctx.Err()
+			case <-time.After(backoff << uint(i-1)):
+				resp, err = c.do(ctx, method, path, body)
+			}
+		} else {
+			resp, err = c.do(ctx, method, path, body)
+		}
+		result.Attempts++
+		if !retryable(resp, err) || ctx.Err() != nil {
+			break
+		}
+		if resp != nil && i < attempts-1 {
+			resp.Body.Close()
+		}
+	}
+	c.breaker.record(generation, !retryable(resp, err))
+
+	result.Duration = time.Since(start)
+	result.Err = err
+	if resp != nil {
+		result.StatusCode = resp.StatusCode
+	}
+	c.observe(result)
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", c.name, err)
+	}
+	defer resp.Body.Close()
+
+	url := c.base + path
+	if len(expect) == 0 {
+		expect = []int{http.StatusOK}
+	}
+	ok := false
+	for _, code := range expect {
+		if resp.StatusCode == code {
+			ok = true
+			break
+		}
+	}
+	if !ok {
+		switch resp.StatusCode {
+		case http.StatusNotFound:
+			return fmt.Errorf("%s: %s %s: %w", c.name, method, url, ErrNotFound)
+		case http.StatusConflict:
+			return fmt.Errorf("%s: %s %s: %w", c.name, method, url, ErrConflict)
+		case http.StatusForbidden:
+			return fmt.Errorf("%s: %s %s: %w", c.name, method, url, ErrForbidden)
+		}
+		return &StatusError{
+			Service:    c.name,
+			Method:     method,
+			URL:        url,
+			StatusCode: resp.StatusCode,
+		}
+	}
+
+	if out == nil {
+		return nil
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s: error reading response: %w", c.name, err)
+	}
+	// some endpoints respond with an empty body rather than a 404
+	if len(b) == 0 {
+		return fmt.Errorf("%s: %s %s: empty response: %w", c.name, method, url, ErrNotFound)
+	}
+	if err := json.Unmarshal(b, out); err != nil {
+		return fmt.Errorf("%s: error decoding response (%s): %w", c.name, string(b), err)
+	}
+	return nil
+}
+
+// Ping checks that the service is reachable: any response that isn't a server error will do. It's a single attempt
+// that bypasses the circuit breaker and isn't observed, so health checks don't skew anything.
+func (c *Client) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	resp, err := c.do(ctx, http.MethodGet, "/", nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", c.name, err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return &StatusError{
+			Service:    c.name,
+			Method:     http.MethodGet,
+			URL:        c.base + "/",
+			StatusCode: resp.StatusCode,
+		}
+	}
+	return nil
+}
+
+func (c *Client) observe(r Result) {
+	for _, f := range c.observers {
+		f(r)
+	}
+}
+
+// do makes a single attempt at a request.
+func (c *Client) do(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.base+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Add("Content-Type", applicationJSON)
+	req.Header.Add("Accept", applicationJSON)
+	req.Header.Add(RequestIDHeader, RequestID(ctx))
+	if c.basicuser != "" && c.basicpass != "" {
+		req.SetBasicAuth(c.basicuser, c.basicpass)
+	}
+	return c.http.Do(req)
+}
+
+// retryable reports whether a request failed in a way that's worth trying again, which is also what counts as a
+// failure for the circuit breaker: the service didn't respond, or responded with a server error.
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode >= http.StatusInternalServerError
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying id, which will be sent to the services as the request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID in ctx, generating a random one if there isn't one.
+func RequestID(ctx context.Context) string {
+	if id, ok := ctx.Value(requestIDKey{}).(string); ok && id != "" {
+		return id
+	}
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,128 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// server responds with each status in turn, then the last one forever, recording the request IDs it's sent.
+type server struct {
+	sync.Mutex
+	statuses []int
+	ids      []string
+	// hang makes it wait on the request being given up on instead of responding.
+	hang bool
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	s.ids = append(s.ids, r.Header.Get(RequestIDHeader))
+	status := s.statuses[0]
+	if len(s.statuses) > 1 {
+		s.statuses = s.statuses[1:]
+	}
+	s.Unlock()
+
+	if s.hang {
+		<-r.Context().Done()
+		return
+	}
+	w.WriteHeader(status)
+	w.Write([]byte(`{}`))
+}
+
+func (s *server) requests() []string {
+	s.Lock()
+	defer s.Unlock()
+	return s.ids
+}
+
+// newTestClient returns a client for s, the result of its last request and a function to stop s.
+func newTestClient(s *server, opts ...Option) (*Client, *Result, func()) {
+	ts := httptest.NewServer(s)
+	var last Result
+	opts = append(opts, Observe(func(r Result) { last = r }))
+	return New("test", ts.URL, opts...), &last, ts.Close
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		statuses []int
+		attempts int
+		err      bool
+	}{
+		{name: "success", method: http.MethodGet, statuses: []int{200}, attempts: 1},
+		{name: "retried", method: http.MethodGet, statuses: []int{500, 502, 200}, attempts: 3},
+		{name: "out of retries", method: http.MethodGet, statuses: []int{500}, attempts: 3, err: true},
+		{name: "not found isn't retried", method: http.MethodGet, statuses: []int{404, 200}, attempts: 1, err: true},
+		{name: "post isn't retried", method: http.MethodPost, statuses: []int{500, 200}, attempts: 1, err: true},
+		{name: "put is", method: http.MethodPut, statuses: []int{500, 200}, attempts: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &server{statuses: tt.statuses}
+			c, result, stop := newTestClient(s)
+			defer stop()
+			err := c.Do(WithRequestID(context.Background(), "abc"), tt.method, "/", nil, &struct{}{})
+			if (err != nil) != tt.err {
+				t.Errorf("got error %v, want one: %v", err, tt.err)
+			}
+			if result.Attempts != tt.attempts {
+				t.Errorf("%d attempts, want %d", result.Attempts, tt.attempts)
+			}
+			for _, id := range s.requests() {
+				if id != "abc" {
+					t.Errorf("sent request ID %q, want abc", id)
+				}
+			}
+		})
+	}
+}
+
+func TestTimeoutCoversRetries(t *testing.T) {
+	s := &server{statuses: []int{200}, hang: true}
+	c, result, stop := newTestClient(s, Timeout(100*time.Millisecond), Retries(5))
+	defer stop()
+
+	start := time.Now()
+	err := c.Do(context.Background(), http.MethodGet, "/", nil, nil)
+	if err == nil {
+		t.Fatal("no error from a service that never responds")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("took %s with a 100ms timeout", elapsed)
+	}
+	if result.Attempts != 1 {
+		t.Errorf("%d attempts, but the timeout was up after the first", result.Attempts)
+	}
+}
+
+func TestBreakerShortCircuits(t *testing.T) {
+	s := &server{statuses: []int{500}}
+	c, _, stop := newTestClient(s, Retries(0), Breaker(2, time.Minute))
+	defer stop()
+
+	for i := 0; i < 2; i++ {
+		c.Do(context.Background(), http.MethodGet, "/", nil, nil)
+	}
+	if c.State() != StateOpen {
+		t.Fatalf("%s after 2 failures, want down", c.State())
+	}
+
+	err := c.Do(context.Background(), http.MethodGet, "/", nil, nil)
+	var unavailable *UnavailableError
+	if !errors.As(err, &unavailable) {
+		t.Errorf("got %v, want an UnavailableError", err)
+	}
+	if n := len(s.requests()); n != 2 {
+		t.Errorf("the service got %d requests, want 2", n)
+	}
+}
@@ -0,0 +1,135 @@
+package backend
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Defaults for the circuit breaker.
+const (
+	DefaultFailureThreshold = 5
+	DefaultCooldown         = 30 * time.Second
+)
+
+// A State is the state of a circuit breaker.
+type State int
+
+const (
+	// StateClosed means requests are going through as normal.
+	StateClosed State = iota
+	// StateOpen means the service is failing and requests are short-circuited.
+	StateOpen
+	// StateHalfOpen means the cooldown has passed and a single request is being let through to test the service.
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "up"
+	case StateOpen:
+		return "down"
+	case StateHalfOpen:
+		return "recovering"
+	}
+	return "unknown"
+}
+
+// An UnavailableError is returned without making a request when a service's circuit breaker is open.
+type UnavailableError struct {
+	Service string
+}
+
+func (e *UnavailableError) Error() string {
+	return fmt.Sprintf("%s: service unavailable", e.Service)
+}
+
+// A StateChangeFunc is called when a service's circuit breaker changes state.
+type StateChangeFunc func(service string, from, to State)
+
+// breaker is a circuit breaker: after threshold consecutive failures it opens and rejects requests until cooldown
+// has passed, then lets one through. If that succeeds it closes again, otherwise it stays open for another cooldown.
+type breaker struct {
+	sync.Mutex
+	name      string
+	threshold int
+	cooldown  time.Duration
+	onChange  StateChangeFunc
+
+	state    State
+	failures int
+	openedAt time.Time
+	trying   bool
+	// generation goes up with every change of state, so a request's outcome only counts in the state it was
+	// allowed in.
+	generation uint64
+}
+
+// allow reports whether a request can be made, and the generation to record its outcome with.
+func (b *breaker) allow() (uint64, bool) {
+	b.Lock()
+	defer b.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return 0, false
+		}
+		b.setState(StateHalfOpen)
+		b.trying = true
+		return b.generation, true
+	case StateHalfOpen:
+		// only one request at a time gets to test the waters
+		if b.trying {
+			return 0, false
+		}
+		b.trying = true
+		return b.generation, true
+	}
+	return b.generation, true
+}
+
+// record the outcome of a request, allowed in generation.
+func (b *breaker) record(generation uint64, success bool) {
+	b.Lock()
+	defer b.Unlock()
+
+	// it's changed state since, ie a request from before it opened finishing while another's testing the service:
+	// it's the other one that says whether the service has recovered
+	if generation != b.generation {
+		return
+	}
+
+	b.trying = false
+	if success {
+		b.failures = 0
+		if b.state != StateClosed {
+			b.setState(StateClosed)
+		}
+		return
+	}
+
+	b.failures++
+	if b.state == StateHalfOpen || (b.state == StateClosed && b.failures >= b.threshold) {
+		b.openedAt = time.Now()
+		b.setState(StateOpen)
+	}
+}
+
+func (b *breaker) current() State {
+	b.Lock()
+	defer b.Unlock()
+	return b.state
+}
+
+// setState must be called with the lock held.
+func (b *breaker) setState(s State) {
+	from := b.state
+	b.state = s
+	b.generation++
+	if b.onChange != nil && from != s {
+		// don't make the callback wait on the lock (or block requests while it PMs someone)
+		go b.onChange(b.name, from, s)
+	}
+}
@@ -0,0 +1,98 @@
+package backend
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestBreaker() *breaker {
+	return &breaker{name: "test", threshold: 2, cooldown: time.Minute}
+}
+
+// cool makes the breaker's cooldown over.
+func cool(b *breaker) {
+	b.openedAt = time.Now().Add(-b.cooldown)
+}
+
+func TestBreakerOpens(t *testing.T) {
+	b := newTestBreaker()
+
+	gen, ok := b.allow()
+	if !ok {
+		t.Fatal("closed breaker didn't allow a request")
+	}
+	b.record(gen, false)
+	if b.current() != StateClosed {
+		t.Fatalf("opened after 1 failure, with a threshold of 2")
+	}
+
+	// a success in between starts the count again
+	gen, _ = b.allow()
+	b.record(gen, true)
+	gen, _ = b.allow()
+	b.record(gen, false)
+	if b.current() != StateClosed {
+		t.Fatalf("opened after 1 failure since a success")
+	}
+
+	gen, _ = b.allow()
+	b.record(gen, false)
+	if b.current() != StateOpen {
+		t.Fatalf("%s after 2 failures in a row, want down", b.current())
+	}
+	if _, ok := b.allow(); ok {
+		t.Error("open breaker allowed a request before the cooldown")
+	}
+}
+
+func TestBreakerRecovers(t *testing.T) {
+	for _, success := range []bool{true, false} {
+		b := newTestBreaker()
+		b.setState(StateOpen)
+		cool(b)
+
+		gen, ok := b.allow()
+		if !ok || b.current() != StateHalfOpen {
+			t.Fatalf("%s and allowed %v after the cooldown, want recovering and allowed", b.current(), ok)
+		}
+		if _, ok := b.allow(); ok {
+			t.Fatal("a second request was allowed while recovering")
+		}
+
+		b.record(gen, success)
+		want := StateOpen
+		if success {
+			want = StateClosed
+		}
+		if b.current() != want {
+			t.Errorf("%s after the test request's success was %v, want %s", b.current(), success, want)
+		}
+	}
+}
+
+func TestBreakerIgnoresLateRecords(t *testing.T) {
+	for _, success := range []bool{true, false} {
+		b := newTestBreaker()
+		// allowed while it was closed, and still going after it's opened and cooled down
+		late, _ := b.allow()
+		b.setState(StateOpen)
+		cool(b)
+		probe, ok := b.allow()
+		if !ok {
+			t.Fatal("not allowed after the cooldown")
+		}
+
+		b.record(late, success)
+		if b.current() != StateHalfOpen {
+			t.Errorf("%s after a late record (success %v), want recovering", b.current(), success)
+		}
+		if _, ok := b.allow(); ok {
+			t.Errorf("a late record (success %v) let another request in while recovering", success)
+		}
+
+		b.record(probe, true)
+		if b.current() != StateClosed {
+			t.Errorf("%s after the test request succeeded, want up", b.current())
+		}
+	}
+}
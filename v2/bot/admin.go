@@ -0,0 +1,246 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// adminErrors is how many errors `admin errors` shows.
+const adminErrors = 10
+
+func init() {
+	registerCommand(&commandSpec{
+		name:        "admin",
+		note:        "my owner only, in a PM",
+		description: "see what I can do for you",
+		examples:    []string{"admin", "admin leave 123456789", "admin status under maintenance"},
+		requires:    []requirement{owner, inPM},
+		// Discord would show it to everyone
+		noSlash: true,
+		subcommands: []*commandSpec{
+			{name: "guilds", description: "list the servers I'm in", run: admin((*Bot).adminGuilds)},
+			{
+				name:        "leave",
+				description: "leave a server",
+				args:        []arg{{name: "server", description: "the server's ID"}},
+				run: func(a args, m *discordgo.MessageCreate, s *discordgo.Session) string {
+					return current.adminLeave(a.String("server"))
+				},
+			},
+			{name: "stats", description: "see how I'm doing", run: admin((*Bot).adminStats)},
+			{
+				name:        "pms",
+				description: "see how many PMs I'm waiting on a reply to, or stop waiting on them",
+				args:        []arg{{name: "clear", choices: []string{"clear"}, optional: true}},
+				run: func(a args, m *discordgo.MessageCreate, s *discordgo.Session) string {
+					if a.Has("clear") {
+						return fmt.Sprintf("stopped waiting on %d PMs", clearPMs())
+					}
+					return fmt.Sprintf("I'm waiting on %d PMs. `admin pms clear` to stop waiting on them", len(current.ActivePMs()))
+				},
+			},
+			{
+				name:        "reload",
+				description: "reload my config",
+				run: func(a args, m *discordgo.MessageCreate, s *discordgo.Session) string {
+					if _, err := current.Reload(); err != nil {
+						return fmt.Sprintf("error reloading config: %s", err)
+					}
+					// Reload PMs the report
+					return ""
+				},
+			},
+			{
+				name:        "broadcast",
+				description: "send a message to every server that wants announcements",
+				args:        []arg{{name: "message", variadic: true, raw: true}},
+				run: func(a args, m *discordgo.MessageCreate, s *discordgo.Session) string {
+					// the words have lost their line breaks
+					return current.adminBroadcast(rawText(m.Content, 2))
+				},
+			},
+			{
+				name:        "status",
+				description: "set what I'm playing, or go back to the version",
+				args:        []arg{{name: "text", variadic: true, optional: true, raw: true}},
+				run: func(a args, m *discordgo.MessageCreate, s *discordgo.Session) string {
+					status := a.String("text")
+					current.update(func(s *settings) { s.status = status })
+					current.updateStatus()
+					if status == "" {
+						return "my status is back to the version"
+					}
+					return "my status is now `" + status + "`"
+				},
+			},
+			{name: "errors", description: "see what's gone wrong recently", run: admin((*Bot).adminErrors)},
+		},
+	})
+}
+
+// admin returns a handler for an admin command that doesn't take any arguments.
+func admin(f func(b *Bot) string) handler {
+	return func(a args, m *discordgo.MessageCreate, s *discordgo.Session) string {
+		return f(current)
+	}
+}
+
+func (b *Bot) adminGuilds() string {
+	servers, err := b.Servers()
+	if err != nil {
+		log.Printf("error getting servers: %s", err)
+		return "sorry, I couldn't get the servers I'm in"
+	}
+	if len(servers) == 0 {
+		return "I'm not in any servers"
+	}
+
+	message := fmt.Sprintf("I'm in %d servers:\n", len(servers))
+	for _, server := range servers {
+		message += fmt.Sprintf("\n**%s** (`%s`)", server.Name, server.ID)
+		if guild, err := b.Server(server.ID); err == nil {
+			message += fmt.Sprintf(": %d members", guild.MemberCount)
+		}
+	}
+	return message
+}
+
+func (b *Bot) adminLeave(server string) string {
+	guild, err := b.Server(server)
+	if err != nil {
+		return fmt.Sprintf("I'm not in `%s`", server)
+	}
+	if err := b.session.GuildLeave(server); err != nil {
+		log.Printf("error leaving %s: %s", server, err)
+		return fmt.Sprintf("sorry, I couldn't leave **%s**", guild.Name)
+	}
+	return fmt.Sprintf("left **%s**", guild.Name)
+}
+
+func (b *Bot) adminStats() string {
+	gateway := "disconnected"
+	if b.Connected() {
+		gateway = fmt.Sprintf("connected (%s heartbeat)", b.session.HeartbeatLatency().Round(time.Millisecond))
+	}
+	servers := "unknown"
+	if list, err := b.Servers(); err == nil {
+		servers = fmt.Sprint(len(list))
+	}
+	commands := "unknown"
+	if usage, err := b.Usage("", time.Now().Add(-24*time.Hour)); err == nil {
+		commands = fmt.Sprintf("%d from %d users", usage.Total, usage.Users)
+	} else {
+		log.Printf("error summarizing the access log: %s", err)
+	}
+	battling := 0
+	if p := currentPVP(); p != nil {
+		for _, users := range p.Battling() {
+			battling += len(users)
+		}
+	}
+
+	message := fmt.Sprintf("**version**: %s", b.settings().version)
+	message += fmt.Sprintf("\n**up for**: %s", time.Since(b.started).Round(time.Second))
+	message += fmt.Sprintf("\n**gateway**: %s", gateway)
+	message += fmt.Sprintf("\n**servers**: %s", servers)
+	message += fmt.Sprintf("\n**commands in the last day**: %s", commands)
+	message += fmt.Sprintf("\n**PMs waiting on a reply**: %d", len(b.ActivePMs()))
+	message += fmt.Sprintf("\n**users looking for battles**: %d", battling)
+	return message
+}
+
+func (b *Bot) adminBroadcast(text string) string {
+	results, err := b.Broadcast(&Message{Content: text}, false)
+	if err != nil {
+		log.Printf("error broadcasting: %s", err)
+		return "sorry, I couldn't broadcast that: " + err.Error()
+	}
+
+	var sent, skipped int
+	var failed []string
+	for _, result := range results {
+		switch {
+		case result.Sent:
+			sent++
+		case result.Error != "":
+			failed = append(failed, fmt.Sprintf("**%s**: %s", b.serverName(result.Guild), result.Error))
+		default:
+			skipped++
+		}
+	}
+	message := fmt.Sprintf("sent to %d servers, skipped %d that didn't want it", sent, skipped)
+	if len(failed) > 0 {
+		message += fmt.Sprintf(", and couldn't send to %d:\n\n%s", len(failed), strings.Join(failed, "\n"))
+	}
+	return message
+}
+
+// serverName returns the name of a server, or its ID if it isn't known.
+func (b *Bot) serverName(server string) string {
+	if guild, err := b.Server(server); err == nil {
+		return guild.Name
+	}
+	return server
+}
+
+func (b *Bot) adminErrors() string {
+	errs := b.RecentErrors()
+	if len(errs) == 0 {
+		return "nothing's gone wrong since I started"
+	}
+	if len(errs) > adminErrors {
+		errs = errs[:adminErrors]
+	}
+
+	message := "the most recent errors:\n"
+	for _, e := range errs {
+		message += fmt.Sprintf("\n%s ago, %s", time.Since(e.Time).Round(time.Second), e.Kind)
+		if e.Command != "" {
+			where := "a PM"
+			if e.Guild != "" {
+				where = "**" + b.serverName(e.Guild) + "**"
+			}
+			message += fmt.Sprintf(" in `%s` from <@%s> in %s", e.Command, e.User, where)
+		}
+		message += fmt.Sprintf(": `%s` (`%s`)", e.Message, e.Signature)
+	}
+	return message
+}
+
+// updateStatus sets what wobbotfet is playing: the status the owner set, or the version if they haven't.
+func (b *Bot) updateStatus() {
+	s := b.settings()
+	status := s.status
+	if status == "" {
+		status = s.version
+	}
+	if err := b.session.UpdateStatus(0, status); err != nil {
+		log.Printf("error updating status: %s", err)
+	}
+}
+
+// keepStatus sets the status whenever the gateway connects, since a new session doesn't have it.
+func (b *Bot) keepStatus() {
+	b.session.AddHandler(func(s *discordgo.Session, e *discordgo.Ready) {
+		b.updateStatus()
+	})
+}
+
+// rawText returns what's after the first n words of a message as it was typed: not lowercased, and with its line
+// breaks.
+func rawText(content string, n int) string {
+	text := strings.TrimSpace(content)
+	for i := 0; i < n; i++ {
+		end := strings.IndexFunc(text, unicode.IsSpace)
+		if end < 0 {
+			return ""
+		}
+		text = strings.TrimLeftFunc(text[end:], unicode.IsSpace)
+	}
+	return text
+}
@@ -3,66 +3,215 @@ package bot
 import (
 	"fmt"
 	"log"
-	"net/http"
-	"os"
 	"regexp"
-	"runtime/debug"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
 
+	"github.com/Sigafoos/wobbotfet/config"
 	"github.com/bwmarrin/discordgo"
 )
 
-var (
-	client    = &http.Client{}
-	mentionre = regexp.MustCompile(`<@!?\d+>`)
-)
-
-var (
-	access *log.Logger
-	aLog   *os.File
-)
+var mentionre = regexp.MustCompile(`<@!?\d+>`)
 
 const (
 	FloorHatched = "hatched"
 )
 
-var FloorMap map[string]string
+// FloorMap is how a Pokemon was obtained, if that sets a minimum IV.
+var FloorMap = map[string]string{
+	"raid":     FloorHatched,
+	"hatch":    FloorHatched,
+	"hatched":  FloorHatched,
+	"research": FloorHatched,
+}
 
 type Bot struct {
-	owner   *discordgo.User
-	pm      *discordgo.Channel
 	session *discordgo.Session
+	// applied is the *settings the bot's running with. Use settings and update.
+	applied    atomic.Value
+	settingsMu sync.Mutex
+	// reloadMu keeps two reloads from happening at once.
+	reloadMu sync.Mutex
+	started  time.Time
+	// connected is whether the gateway is connected: gatewayConnected, or one of the others if it isn't. Use
+	// Connected.
+	connected int32
+}
+
+// settings are what can change while wobbotfet's running: a reload changes most of them, and the owner can change the
+// status. They're replaced as a whole rather than modified, so they can be read from any goroutine without a lock.
+type settings struct {
+	config *config.Config
+	owner  *discordgo.User
+	// pm is the PM channel with the owner.
+	pm      *discordgo.Channel
+	version string
+	// status is what the owner set wobbotfet to be playing, instead of the version.
+	status string
+}
+
+// settings returns what the bot's running with.
+func (b *Bot) settings() *settings {
+	return b.applied.Load().(*settings)
+}
+
+// update replaces the settings with a copy that f has changed.
+func (b *Bot) update(f func(s *settings)) {
+	b.settingsMu.Lock()
+	defer b.settingsMu.Unlock()
+
+	s := *b.settings()
+	f(&s)
+	b.applied.Store(&s)
 }
 
 type command func([]string, *discordgo.MessageCreate, *discordgo.Session) string
-type commandMap map[string]command
 
 var (
-	commands  commandMap
-	activePMs map[string]command
+	activePMs = make(map[string]command)
+	pmsMu     sync.Mutex
 )
 
-var help []string
+var (
+	// commandsMu guards commands, aliases and order, which change when the config is reloaded
+	commandsMu sync.RWMutex
+	// the maps are made here rather than in init, since every file's init registers commands
+	commands = make(map[string]*commandSpec)
+	// aliases are the other names commands can be used by
+	aliases = make(map[string]string)
+	// the order commands were registered in, for the help text
+	order []string
+)
 
-func init() {
-	FloorMap = make(map[string]string)
-	FloorMap["raid"] = FloorHatched
-	FloorMap["hatch"] = FloorHatched
-	FloorMap["hatched"] = FloorHatched
-	FloorMap["research"] = FloorHatched
+// registerCommand adds a command, replacing any with the same name.
+func registerCommand(sp *commandSpec) {
+	sp.setParents()
 
-	commands = make(map[string]command)
-	activePMs = make(map[string]command)
+	commandsMu.Lock()
+	defer commandsMu.Unlock()
+
+	if old, exists := commands[sp.name]; exists {
+		for _, alias := range old.aliases {
+			delete(aliases, alias)
+		}
+	} else {
+		order = append(order, sp.name)
+	}
+	commands[sp.name] = sp
+	for _, alias := range sp.aliases {
+		aliases[alias] = sp.name
+	}
+}
+
+func unregisterCommand(key string) {
+	commandsMu.Lock()
+	defer commandsMu.Unlock()
+
+	if sp, ok := commands[key]; ok {
+		for _, alias := range sp.aliases {
+			delete(aliases, alias)
+		}
+	}
+	delete(commands, key)
+	for i, v := range order {
+		if v == key {
+			order = append(order[:i], order[i+1:]...)
+			break
+		}
+	}
+}
+
+// getCommand returns a command by its name or one of its aliases.
+func getCommand(key string) (*commandSpec, bool) {
+	commandsMu.RLock()
+	defer commandsMu.RUnlock()
+
+	if name, ok := aliases[key]; ok {
+		key = name
+	}
+	sp, ok := commands[key]
+	return sp, ok
+}
+
+// commandNames returns the registered commands in the order they were registered.
+func commandNames() []string {
+	commandsMu.RLock()
+	defer commandsMu.RUnlock()
+
+	return append([]string{}, order...)
 }
 
-func registerCommand(key string, f command, helpText string) {
-	commands[key] = f
-	help = append(help, fmt.Sprintf("**%s**: %s", key, helpText))
+// helpText returns the one line help for a command.
+func helpText(key string) string {
+	sp, ok := getCommand(key)
+	if !ok {
+		return ""
+	}
+	return sp.help()
+}
+
+// commandRequirements returns what has to be true for a command to be used.
+func commandRequirements(key string) []requirement {
+	sp, ok := getCommand(key)
+	if !ok {
+		return nil
+	}
+	return sp.requires
 }
 
 // say "hey I'm expecting a PM from this user about something"
 func expectPM(pm string, next command) {
+	pmsMu.Lock()
 	activePMs[pm] = next
+	pmsMu.Unlock()
+
+	publish(StreamConversation, "", &Conversation{Channel: pm, State: ConversationWaiting})
+}
+
+// takePM returns (and forgets) what to do with a PM, if we're expecting one.
+func takePM(pm string) (command, bool) {
+	pmsMu.Lock()
+	next, ok := activePMs[pm]
+	delete(activePMs, pm)
+	pmsMu.Unlock()
+
+	if ok {
+		closeQuestion(pm)
+		publish(StreamConversation, "", &Conversation{Channel: pm, State: ConversationReplied})
+	}
+	return next, ok
+}
+
+// expirePM stops waiting on a PM that's gone unanswered for too long.
+func expirePM(pm string) {
+	pmsMu.Lock()
+	_, ok := activePMs[pm]
+	delete(activePMs, pm)
+	pmsMu.Unlock()
+
+	if ok {
+		publish(StreamConversation, "", &Conversation{Channel: pm, State: ConversationExpired})
+	}
+}
+
+// clearPMs stops waiting on every PM, returning how many there were.
+func clearPMs() int {
+	pmsMu.Lock()
+	cleared := make([]string, 0, len(activePMs))
+	for pm := range activePMs {
+		cleared = append(cleared, pm)
+	}
+	activePMs = make(map[string]command)
+	pmsMu.Unlock()
+
+	for _, pm := range cleared {
+		closeQuestion(pm)
+		publish(StreamConversation, "", &Conversation{Channel: pm, State: ConversationCleared})
+	}
+	return len(cleared)
 }
 
 func startPM(s *discordgo.Session, user string) *discordgo.Channel {
@@ -74,57 +223,56 @@ func startPM(s *discordgo.Session, user string) *discordgo.Channel {
 	return pm
 }
 
-type Query struct {
-	League  string
-	Pokemon string
-	Atk     string
-	Def     string
-	HP      string
-	Floor   string
-}
-
-func New(auth string) *Bot {
-	var err error
-	aLog, err = os.OpenFile("access.log", os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
-	if err != nil {
-		log.Fatalf("cannot open access log for writing: %s\n", err)
-	}
-	access = log.New(aLog, "", log.Ldate|log.Ltime)
+// New returns a Bot for the config, with the commands for each configured service registered.
+func New(c *config.Config) *Bot {
+	openEventLog(c.AccessLog)
+	openGuildConfig(c.GuildConfig)
 
-	session, err := discordgo.New(auth)
+	session, err := discordgo.New("Bot " + c.Token)
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	b := &Bot{session: session}
-	session.AddHandler(b.readMessage)
-
-	if owner := os.Getenv("DISCORD_OWNER"); owner != "" {
-		b.owner = &discordgo.User{ID: owner}
-	} else {
-		log.Println("no DISCORD_OWNER specified; will not PM owner")
+	initial := &settings{config: c, version: c.Version}
+	if c.Owner != "" {
+		initial.owner = &discordgo.User{ID: c.Owner}
 	}
+	b.applied.Store(initial)
+	session.AddHandler(b.readMessage)
+	session.AddHandler(readReaction)
+	b.openErrorLog(c.Errors)
+	b.trackConnection()
+	b.trackServers()
+	b.keepStatus()
+	b.handleSlashCommands()
+	b.registerMetrics()
+	current = b
+
+	setupServices(c)
+	setupRateLimits(c.RateLimit)
+	setupFeatures(c.DisabledCommands)
 
 	return b
 }
 
 func (b *Bot) Start() {
+	b.started = time.Now()
 	err := b.session.Open()
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	if b.owner != nil {
-		b.pm, err = b.session.UserChannelCreate(b.owner.ID)
+	if owner := b.settings().owner; owner != nil {
+		pm, err := b.session.UserChannelCreate(owner.ID)
 		if err != nil {
 			log.Printf("error opening PM with owner: %s", err.Error())
+		} else {
+			b.update(func(s *settings) { s.pm = pm })
 		}
 	}
-	if os.Getenv("VERSION") != "" {
-		b.session.UpdateStatus(0, os.Getenv("VERSION"))
-	}
 	cmds := "known commands:\n"
-	for k := range commands {
+	for _, k := range commandNames() {
 		cmds += "- " + k + "\n"
 	}
 	b.PM(cmds)
@@ -133,29 +281,29 @@ func (b *Bot) Start() {
 
 func (b *Bot) Close() {
 	b.PM("going down")
+	// send what's left of the digest while we can
+	errorLog.Close()
 	b.session.Close()
 
-	err := aLog.Close()
+	err := events.Close()
 	if err != nil {
 		log.Printf("error closing access log: %s\n", err)
 	}
 }
 
 func (b *Bot) PM(message string) {
-	if b.pm == nil {
+	pm := b.settings().pm
+	if pm == nil {
 		return
 	}
-	b.session.ChannelMessageSend(b.pm.ID, message)
-}
-
-// Servers returns a list of server ids the bot is connected to. It will use its session to fill in the server details.
-func (b *Bot) Servers() ([]*discordgo.UserGuild, error) {
-	servers, err := b.session.UserGuilds(100, "", "")
-	return servers, err
+	b.session.ChannelMessageSend(pm.ID, message)
 }
 
 // ActivePMs returns a list of open PM channels.
 func (b *Bot) ActivePMs() []string {
+	pmsMu.Lock()
+	defer pmsMu.Unlock()
+
 	pms := make([]string, len(activePMs))
 	i := 0
 	for k := range activePMs {
@@ -171,79 +319,85 @@ func (b *Bot) Roles(server string) ([]*discordgo.Role, error) {
 }
 
 func (b *Bot) readMessage(s *discordgo.Session, m *discordgo.MessageCreate) {
-	defer func() {
-		if r := recover(); r != nil {
-			b.PM(fmt.Sprintf("recovered from panic: %v\n\n`%v`", r, string(debug.Stack())))
-		}
-	}()
+	defer recoverEvent(m)
 
 	// ignore messages posted by wobbotfet
 	if m.Author.ID == s.State.User.ID {
 		return
 	}
 
-	// only check for a mention if it's not a PM
-	if m.GuildID != "" {
-		mentioned := false
-		for _, u := range m.Mentions {
-			if u.ID == s.State.User.ID {
-				mentioned = true
-				break
-			}
-		}
-
-		if !mentioned {
-			return
-		}
+	message, ok := commandText(m, s)
+	if !ok {
+		return
 	}
 
-	err := s.ChannelTyping(m.ChannelID)
-	if err != nil {
-		log.Printf("error sending typing call: %s", err.Error())
-	}
+	c, f, args := route(message, m)
+	handle(c, f)(args, m, s)
+}
 
-	message := mentionre.ReplaceAllString(m.Content, "")
-	message = strings.Replace(message, "  ", " ", -1)
-	message = strings.TrimSpace(message)
-	pieces := strings.Split(strings.ToLower(message), " ")
-
-	var response string
-	if pm, ok := activePMs[m.ChannelID]; ok {
-		delete(activePMs, m.ChannelID)
-		// we don't want to lowercase PM responses
-		response = pm(strings.Split(message, " "), m, s)
-	} else if f, ok := commands[pieces[0]]; !ok {
-		response = fmt.Sprintf("I don't have a `%s` command", pieces[0])
-	} else {
-		response = f(pieces[1:], m, s)
+// legacyAliases are v1's commands, which didn't need a mention.
+var legacyAliases = map[string]string{
+	"!rank":       "rank",
+	"!vrank":      "vrank",
+	"!betterthan": "betterthan",
+}
+
+// commandText returns the command in a message, without whatever marked it as being for wobbotfet (a mention, the
+// guild's prefix or a v1 alias). If the message isn't for wobbotfet it returns false.
+func commandText(m *discordgo.MessageCreate, s *discordgo.Session) (string, bool) {
+	// everything in a PM is for us
+	if m.GuildID == "" {
+		return cleanMessage(m.Content), true
 	}
 
-	messages := b.splitResponse(response)
+	for _, u := range m.Mentions {
+		if u.ID == s.State.User.ID {
+			return cleanMessage(mentionre.ReplaceAllString(m.Content, "")), true
+		}
+	}
 
-	for _, message := range messages {
-		if m.GuildID != "" {
-			message = m.Author.Mention() + ": " + message
+	c := guildConfig(m.GuildID)
+	content := strings.TrimSpace(m.Content)
+	if c.Prefix != "" && strings.HasPrefix(strings.ToLower(content), strings.ToLower(c.Prefix)) {
+		rest := content[len(c.Prefix):]
+		// `!wob` shouldn't match `!wobble`, but `!` should match `!rank`
+		last := rune(c.Prefix[len(c.Prefix)-1])
+		if !unicode.IsLetter(last) && !unicode.IsDigit(last) || rest == "" || rest[0] == ' ' {
+			return cleanMessage(rest), true
 		}
-		if _, err := s.ChannelMessageSend(m.ChannelID, message); err != nil {
-			log.Printf("error sending message: %s", err)
-			s.ChannelMessageSend(m.ChannelID, "sorry, something's gone wrong")
-			return
+	}
+
+	if c.LegacyAliases {
+		pieces := strings.SplitN(content, " ", 2)
+		if command, ok := legacyAliases[strings.ToLower(pieces[0])]; ok {
+			// `!rank help` was how you got help in v1
+			if len(pieces) > 1 && strings.ToLower(strings.TrimSpace(pieces[1])) == "help" {
+				return "help legacy", true
+			}
+			pieces[0] = command
+			return cleanMessage(strings.Join(pieces, " ")), true
 		}
 	}
 
+	return "", false
+}
+
+// cleanMessage collapses and trims the whitespace in a message.
+func cleanMessage(message string) string {
+	return strings.Join(strings.Fields(message), " ")
 }
 
 // discord has a 2000 character limit. if it's longer than that, find a good way to split it into multiple messages.
-func (b *Bot) splitResponse(response string) []string {
+func splitResponse(response string) []string {
 	if len(response) <= 2000 {
 		return []string{response}
 	}
 
 	var messages []string
-	splitResponse := strings.Split(response, "\n")
+	lines := strings.Split(response, "\n")
 
 	// ain't gonna lie, haven't tested this bit
-	if len(splitResponse) == 1 {
+	if len(lines) == 1 {
 		log.Printf("%v character message has no line breaks", len(response))
 		for i := 0; i <= len(response)/2000; i++ {
 			start := 2000 * i
@@ -258,14 +412,14 @@ func (b *Bot) splitResponse(response string) []string {
 
 	// send the fewest possible messages, split by line breaks
 	var current string
-	for i, line := range splitResponse {
+	for i, line := range lines {
 		if len(current)+len(line) > 2000 {
 			messages = append(messages, current)
 			current = ""
 		}
 		current += line + "\n"
 
-		if i == len(splitResponse)-1 {
+		if i == len(lines)-1 {
 			messages = append(messages, current)
 		}
 	}
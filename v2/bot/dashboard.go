@@ -0,0 +1,168 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sigafoos/pvpservice/pvp"
+	"github.com/Sigafoos/wobbotfet/eventlog"
+	"github.com/bwmarrin/discordgo"
+)
+
+// A WantRole is a role for people who want a Pokemon.
+type WantRole struct {
+	Role    *discordgo.Role
+	Pokemon string
+	// Members is how many people have the role.
+	Members int
+}
+
+// WantRoles returns a server's want roles, most wanted first. A want role is a mentionable role with the server's
+// role prefix, which is how wobbotfet creates them; without a prefix that can include roles wobbotfet didn't make.
+func (b *Bot) WantRoles(server string) ([]WantRole, error) {
+	if b.settings().config.Want.URL == "" {
+		return nil, fmt.Errorf("want: %w", ErrNotConfigured)
+	}
+
+	roles, err := b.session.GuildRoles(server)
+	if err != nil {
+		return nil, err
+	}
+	prefix := guildConfig(server).RolePrefix
+
+	counts := make(map[string]int)
+	members, err := guildMembers(b.session, server)
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range members {
+		for _, role := range member.Roles {
+			counts[role]++
+		}
+	}
+
+	wantRoles := []WantRole{}
+	for _, role := range roles {
+		// the @everyone role has the guild's ID
+		if role.ID == server || role.Managed || !role.Mentionable || !strings.HasPrefix(role.Name, prefix) {
+			continue
+		}
+		wantRoles = append(wantRoles, WantRole{
+			Role:    role,
+			Pokemon: strings.TrimPrefix(role.Name, prefix),
+			Members: counts[role.ID],
+		})
+	}
+	sort.SliceStable(wantRoles, func(i, j int) bool {
+		return wantRoles[i].Members > wantRoles[j].Members
+	})
+	return wantRoles, nil
+}
+
+// guildMembers returns every member of a server, a page at a time.
+func guildMembers(s *discordgo.Session, server string) ([]*discordgo.Member, error) {
+	var members []*discordgo.Member
+	after := ""
+	for {
+		page, err := s.GuildMembers(server, after, 1000)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, page...)
+		if len(page) < 1000 {
+			return members, nil
+		}
+		after = page[len(page)-1].User.ID
+	}
+}
+
+// Players returns the PVP players registered on a server.
+func (b *Bot) Players(server string) ([]pvp.Player, error) {
+	if b.settings().config.PVP.URL == "" {
+		return nil, fmt.Errorf("pvp: %w", ErrNotConfigured)
+	}
+	return pvps().Players(context.Background(), server)
+}
+
+// Friendships returns the ultra friendships between a server's PVP players, each pair once.
+func (b *Bot) Friendships(server string) ([]pvp.Friendship, error) {
+	players, err := b.Players(server)
+	if err != nil {
+		return nil, err
+	}
+	registered := make(map[string]bool)
+	for _, player := range players {
+		registered[player.ID] = true
+	}
+
+	var mu sync.Mutex
+	var firstErr error
+	seen := make(map[[2]string]bool)
+	friendships := []pvp.Friendship{}
+
+	// there's no endpoint for a whole server, so ask about a few players at a time
+	sem := make(chan struct{}, 4)
+	var wg sync.WaitGroup
+	for _, player := range players {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(id string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			friends, err := pvps().Friends(context.Background(), id)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			for _, friend := range friends {
+				if !registered[friend.ID] {
+					continue
+				}
+				pair := [2]string{id, friend.ID}
+				if pair[0] > pair[1] {
+					pair[0], pair[1] = pair[1], pair[0]
+				}
+				if seen[pair] {
+					continue
+				}
+				seen[pair] = true
+				friendships = append(friendships, pvp.Friendship{User: pair[0], Friend: pair[1]})
+			}
+		}(player.ID)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return friendships, nil
+}
+
+// BattlingUsers returns the IDs of the users looking for PVP battles on a server.
+func (b *Bot) BattlingUsers(server string) ([]string, error) {
+	if b.settings().config.PVP.URL == "" {
+		return nil, fmt.Errorf("pvp: %w", ErrNotConfigured)
+	}
+	users := []string{}
+	if p := currentPVP(); p != nil {
+		users = append(users, p.Battling()[server]...)
+	}
+	return users, nil
+}
+
+// Usage summarizes the commands handled since then, from the access log. If server is empty, it's every server (and
+// PMs).
+func (b *Bot) Usage(server string, since time.Time) (*eventlog.Usage, error) {
+	return eventlog.Summarize(b.settings().config.AccessLog.Path, since, server)
+}
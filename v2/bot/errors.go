@@ -0,0 +1,155 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/Sigafoos/wobbotfet/backend"
+	"github.com/Sigafoos/wobbotfet/config"
+	"github.com/Sigafoos/wobbotfet/errorlog"
+	"github.com/bwmarrin/discordgo"
+)
+
+// ErrNotConfigured is returned when asking about a service that isn't configured.
+var ErrNotConfigured = errors.New("service isn't configured")
+
+// maxDigestStack is how much of a panic's stack is sent to the owner.
+const maxDigestStack = 1500
+
+var errorLog *errorlog.Aggregator
+
+// openErrorLog starts collecting errors, with a digest PMed to the owner every so often.
+func (b *Bot) openErrorLog(c config.Errors) {
+	errorLog = errorlog.New(c.Recent, c.Digest, b.sendDigest)
+}
+
+// errorMessage logs an error from a service client and returns what to tell the user. Handlers should check for
+// the errors they have a specific response for (ie a Pokemon not existing) before falling back to this.
+func errorMessage(m *discordgo.MessageCreate, err error) string {
+	log.Println(err)
+
+	message := "sorry, something's gone wrong"
+	var unavailable *backend.UnavailableError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &unavailable):
+		message = fmt.Sprintf("sorry, the %s service is down. try again in a bit", unavailable.Service)
+	case errors.As(err, &netErr) && netErr.Timeout():
+		message = "sorry, that's taking too long. try again in a bit"
+	case errors.Is(err, backend.ErrForbidden):
+		message = "sorry, I'm not allowed to do that right now"
+	}
+	// what the user's told is the same for the same problem; the error has the Pokemon, URL, etc
+	reportError(m, 1, message, err.Error())
+	return message
+}
+
+// logError logs an error that shouldn't have happened, and adds it to the owner's digest. m is the message being
+// handled, if there is one. Errors logged with the same format in the same place are counted as the same problem, so
+// anything that changes between them should be in args.
+func logError(m *discordgo.MessageCreate, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	log.Println(message)
+	reportError(m, 1, format, message)
+}
+
+// reportError adds an error to the digest. skip is how many calls up from reportError's caller the error happened,
+// and description is the same every time it does.
+func reportError(m *discordgo.MessageCreate, skip int, description, message string) {
+	location := "unknown"
+	if _, file, line, ok := runtime.Caller(skip + 1); ok {
+		location = fmt.Sprintf("%s:%d", filepath.Base(file), line)
+	}
+	report(m, errorlog.Error{
+		Kind:      errorlog.KindError,
+		Signature: errorlog.ErrorSignature(location, description),
+		Message:   message,
+	})
+}
+
+// reportPanic adds a panic recovered while handling m to the digest.
+func reportPanic(m *discordgo.MessageCreate, value interface{}, stack []byte) {
+	report(m, errorlog.Error{
+		Kind:      errorlog.KindPanic,
+		Signature: errorlog.PanicSignature(value, stack),
+		Message:   fmt.Sprint(value),
+		Stack:     panicStack(string(stack)),
+	})
+}
+
+// report fills in what was being handled when an error happened, and adds it to the digest.
+func report(m *discordgo.MessageCreate, e errorlog.Error) {
+	if m != nil {
+		e.Guild = m.GuildID
+		e.Channel = m.ChannelID
+		if m.Author != nil {
+			e.User = m.Author.ID
+		}
+		e.Command = requestCommand(m.ID)
+	}
+	if errorLog != nil {
+		errorLog.Report(e)
+	}
+}
+
+// panicStack drops the frames of a stack from before the panic (getting the stack, recovering and panicking), so it
+// starts where the panic happened.
+func panicStack(stack string) string {
+	if i := strings.Index(stack, "\npanic("); i >= 0 {
+		// the panic call and its file
+		rest := strings.SplitN(stack[i+1:], "\n", 3)
+		if len(rest) == 3 {
+			return rest[2]
+		}
+	}
+	return stack
+}
+
+// RecentErrors returns the most recent errors, newest first.
+func (b *Bot) RecentErrors() []errorlog.Error {
+	if errorLog == nil {
+		return []errorlog.Error{}
+	}
+	return errorLog.Recent()
+}
+
+// sendDigest PMs the owner what's gone wrong since the last digest. A panic's stack is only sent the first time it
+// happens.
+func (b *Bot) sendDigest(groups []*errorlog.Group) {
+	message := "what's gone wrong since the last digest:\n"
+	for _, g := range groups {
+		message += "\n" + b.describeErrors(g)
+	}
+	for _, m := range splitResponse(message) {
+		b.PM(m)
+	}
+}
+
+func (b *Bot) describeErrors(g *errorlog.Group) string {
+	e := g.First
+	message := fmt.Sprintf("**%d×** %s", g.Count, e.Kind)
+	if e.Command != "" {
+		message += fmt.Sprintf(" in `%s`", e.Command)
+	}
+	switch g.Guilds {
+	case 0:
+	case 1:
+		message += " on **" + b.serverName(e.Guild) + "**"
+	default:
+		message += fmt.Sprintf(" on %d servers", g.Guilds)
+	}
+	message += fmt.Sprintf(": `%s` (`%s`)", e.Message, e.Signature)
+	if g.New && e.Stack != "" {
+		stack := e.Stack
+		if len(stack) > maxDigestStack {
+			stack = stack[:maxDigestStack] + "\n..."
+		}
+		message += "\n```\n" + stack + "\n```"
+	}
+	return message
+}
@@ -0,0 +1,138 @@
+package bot
+
+import (
+	"errors"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sigafoos/wobbotfet/backend"
+	"github.com/Sigafoos/wobbotfet/config"
+	"github.com/Sigafoos/wobbotfet/eventlog"
+	"github.com/bwmarrin/discordgo"
+)
+
+var events *eventlog.Logger
+
+// backendCalls holds the `service:status` of the service calls made while handling each message, and the message's
+// event, by message ID (which is the request ID). Only messages being handled are in here, so calls made outside of
+// handling a message aren't kept forever.
+var backendCalls = struct {
+	sync.Mutex
+	calls  map[string][]string
+	events map[string]*eventlog.Event
+}{calls: make(map[string][]string), events: make(map[string]*eventlog.Event)}
+
+func openEventLog(c config.AccessLog) {
+	f, err := eventlog.OpenRotating(c.Path, eventlog.RotateOptions{
+		MaxSize:    c.MaxSize * 1024 * 1024,
+		MaxAge:     c.RotateEvery,
+		Compress:   c.Compress,
+		MaxBackups: c.MaxBackups,
+	})
+	if err != nil {
+		log.Fatalf("cannot open access log for writing: %s\n", err)
+	}
+	events = eventlog.New(f)
+}
+
+// newEvent starts the event for a message, and starts keeping track of its service calls.
+func newEvent(m *discordgo.MessageCreate) *eventlog.Event {
+	e := &eventlog.Event{
+		Time:    time.Now(),
+		Guild:   m.GuildID,
+		Channel: m.ChannelID,
+		User:    m.Author.ID,
+		Outcome: eventlog.OutcomeOK,
+	}
+
+	backendCalls.Lock()
+	backendCalls.calls[m.ID] = []string{}
+	backendCalls.events[m.ID] = e
+	backendCalls.Unlock()
+	return e
+}
+
+// logEvent fills in the latency and the service calls made while handling message id, and writes the event.
+func logEvent(id string, e *eventlog.Event) {
+	backendCalls.Lock()
+	e.BackendStatus = backendCalls.calls[id]
+	delete(backendCalls.calls, id)
+	delete(backendCalls.events, id)
+	backendCalls.Unlock()
+
+	e.Latency = float64(time.Since(e.Time).Microseconds()) / 1000
+	if e.Command == eventlog.CommandPMReply {
+		// these are IGNs and friend codes
+		e.Args = nil
+	}
+	if e.Outcome == eventlog.OutcomeOK {
+		for _, status := range e.BackendStatus {
+			if !backendOK(status) {
+				e.Outcome = eventlog.OutcomeBackendError
+				break
+			}
+		}
+	}
+
+	publishCommand(e)
+
+	if err := events.Log(e); err != nil {
+		log.Printf("error writing to access log: %s", err)
+	}
+}
+
+// recordBackendCall is a backend.Observer that notes the result of a call made while handling a message.
+func recordBackendCall(r backend.Result) {
+	backendCalls.Lock()
+	defer backendCalls.Unlock()
+	if calls, ok := backendCalls.calls[r.RequestID]; ok {
+		backendCalls.calls[r.RequestID] = append(calls, r.Service+":"+backendStatus(r))
+	}
+}
+
+// requestGuild returns the guild of the message being handled with request ID id, if there is one.
+func requestGuild(id string) string {
+	backendCalls.Lock()
+	defer backendCalls.Unlock()
+	if e, ok := backendCalls.events[id]; ok {
+		return e.Guild
+	}
+	return ""
+}
+
+// requestCommand returns the command of the message being handled with request ID id, if there is one. The command
+// is set before its handler's called, so this is only safe to call from the handler.
+func requestCommand(id string) string {
+	backendCalls.Lock()
+	defer backendCalls.Unlock()
+	if e, ok := backendCalls.events[id]; ok {
+		return e.Command
+	}
+	return ""
+}
+
+// backendStatus is the response's status code, or why there wasn't one.
+func backendStatus(r backend.Result) string {
+	var unavailable *backend.UnavailableError
+	var netErr net.Error
+	switch {
+	case errors.As(r.Err, &unavailable):
+		return "unavailable"
+	case errors.As(r.Err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case r.Err != nil:
+		return "error"
+	}
+	return strconv.Itoa(r.StatusCode)
+}
+
+// backendOK reports whether a `service:status` is a response that isn't a server error. A 404 for a Pokemon that
+// doesn't exist is the user's problem, not the service's.
+func backendOK(status string) bool {
+	code, err := strconv.Atoi(status[strings.LastIndex(status, ":")+1:])
+	return err == nil && code < 500
+}
@@ -0,0 +1,256 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/Sigafoos/wobbotfet/guildconfig"
+	"github.com/bwmarrin/discordgo"
+)
+
+var (
+	// guildsMu guards guilds, which is replaced if a reload moves the guild config. Use guildStore.
+	guildsMu sync.RWMutex
+	guilds   *guildconfig.Store
+)
+
+var channelre = regexp.MustCompile(`^<#(\d+)>$`)
+
+// commands that can't be disabled, or an admin could lock themselves out
+var alwaysEnabled = map[string]bool{
+	"config": true,
+	"help":   true,
+}
+
+func init() {
+	registerCommand(&commandSpec{
+		name:        "config",
+		note:        "server admins only",
+		description: "see how I'm set up on this server, and how to change it",
+		examples:    []string{"config", "config league ultra", "config prefix !wob"},
+		requires:    []requirement{inGuild, guildAdmin},
+		run:         showConfig,
+		subcommands: []*commandSpec{
+			{name: "show", description: "see how I'm set up on this server", run: showConfig},
+			setting("enable", "turn a command back on", "config enable want", arg{name: "command"}, func(c *guildconfig.Config, a args) {
+				c.Enable(commandName(a.String("command")))
+			}),
+			setting("disable", "turn a command off", "config disable want", arg{name: "command"}, func(c *guildconfig.Config, a args) {
+				c.Disable(commandName(a.String("command")))
+			}),
+			setting("league", "set the league for rank commands that don't say", "config league ultra", arg{name: "league", choices: guildconfig.Leagues}, func(c *guildconfig.Config, a args) {
+				c.DefaultLeague = a.String("league")
+			}),
+			setting("roles", "turn creating roles for wants on or off", "config roles off", arg{name: "roles", kind: kindBool}, func(c *guildconfig.Config, a args) {
+				c.CreateRoles = a.Bool("roles")
+			}),
+			setting("prefix", "set a prefix to use instead of mentioning me, ie `!wob`", "config prefix !wob", arg{name: "prefix", clear: true}, func(c *guildconfig.Config, a args) {
+				c.Prefix = a.String("prefix")
+			}),
+			setting("roleprefix", "set the prefix for want roles, ie `want-`", "config roleprefix want-", arg{name: "prefix", clear: true}, func(c *guildconfig.Config, a args) {
+				c.RolePrefix = a.String("prefix")
+			}),
+			setting("legacy", "turn v1's `!rank`, `!vrank` and `!betterthan` on or off", "config legacy off", arg{name: "legacy", kind: kindBool}, func(c *guildconfig.Config, a args) {
+				c.LegacyAliases = a.Bool("legacy")
+			}),
+			setting("pvp", "set the channel pvp battles are announced in", "config pvp #pvp", arg{name: "channel", kind: kindChannel, clear: true}, func(c *guildconfig.Config, a args) {
+				c.PVPChannel = a.String("channel")
+			}),
+			setting("announce", "set the channel announcements from my owner go to", "config announce #announcements", arg{name: "channel", kind: kindChannel, clear: true}, func(c *guildconfig.Config, a args) {
+				c.AnnounceChannel = a.String("channel")
+			}),
+			setting("announcements", "turn announcements from my owner on or off", "config announcements off", arg{name: "announcements", kind: kindBool}, func(c *guildconfig.Config, a args) {
+				c.Announcements = a.Bool("announcements")
+			}),
+			setting("unknown", "turn replying to commands I don't have on or off", "config unknown off", arg{name: "unknown", kind: kindBool}, func(c *guildconfig.Config, a args) {
+				c.UnknownCommands = a.Bool("unknown")
+			}),
+			setting("language", "set the language I speak", "config language en", arg{name: "language", choices: guildconfig.Languages}, func(c *guildconfig.Config, a args) {
+				c.Language = a.String("language")
+			}),
+		},
+	})
+}
+
+// setting returns a `config` subcommand that changes one setting.
+func setting(name, description, example string, value arg, set func(c *guildconfig.Config, a args)) *commandSpec {
+	value.description = description
+	return &commandSpec{
+		name:        name,
+		description: description,
+		examples:    []string{example},
+		args:        []arg{value},
+		run: func(a args, m *discordgo.MessageCreate, s *discordgo.Session) string {
+			c := guildConfig(m.GuildID)
+			set(&c, a)
+			if err := current.SetGuildConfig(m.GuildID, c); err != nil {
+				return err.Error()
+			}
+			return "got it! " + describeConfig(c)
+		},
+	}
+}
+
+func openGuildConfig(path string) {
+	store, err := guildconfig.Open(path)
+	if err != nil {
+		log.Fatalf("cannot open guild config: %s\n", err)
+	}
+	setGuildStore(store)
+}
+
+func setGuildStore(store *guildconfig.Store) {
+	guildsMu.Lock()
+	guilds = store
+	guildsMu.Unlock()
+}
+
+func guildStore() *guildconfig.Store {
+	guildsMu.RLock()
+	defer guildsMu.RUnlock()
+	return guilds
+}
+
+// guildConfig returns the configuration for a guild. PMs get the default.
+func guildConfig(guild string) guildconfig.Config {
+	store := guildStore()
+	if guild == "" || store == nil {
+		return guildconfig.Default()
+	}
+	return store.Get(guild)
+}
+
+// GuildConfig returns the configuration for a server.
+func (b *Bot) GuildConfig(server string) guildconfig.Config {
+	return guildConfig(server)
+}
+
+// SetGuildConfig validates and saves the configuration for a server.
+func (b *Bot) SetGuildConfig(server string, c guildconfig.Config) error {
+	old := guildConfig(server)
+	wasDisabled := make(map[string]bool)
+	for _, cmd := range old.DisabledCommands {
+		wasDisabled[cmd] = true
+	}
+	disabled := make([]string, 0, len(c.DisabledCommands))
+	seen := make(map[string]bool)
+	for _, cmd := range c.DisabledCommands {
+		// aliases are turned off as their command, since that's what's checked
+		cmd = commandName(strings.ToLower(cmd))
+		if seen[cmd] {
+			continue
+		}
+		seen[cmd] = true
+		disabled = append(disabled, cmd)
+		// a command that's gone since it was turned off (ie its service isn't configured any more) can stay off
+		if wasDisabled[cmd] {
+			continue
+		}
+		if _, ok := getCommand(cmd); !ok {
+			return fmt.Errorf("I don't have a `%s` command", cmd)
+		}
+		if alwaysEnabled[cmd] {
+			return fmt.Errorf("`%s` can't be disabled", cmd)
+		}
+	}
+	c.DisabledCommands = disabled
+
+	// otherwise battles and announcements could be posted in someone else's server. only changes are checked, so a
+	// channel that's been deleted doesn't stop anything else being changed.
+	channels := []struct{ name, old, new string }{
+		{"pvp", old.PVPChannel, c.PVPChannel},
+		{"announce", old.AnnounceChannel, c.AnnounceChannel},
+	}
+	for _, channel := range channels {
+		if channel.new == "" || channel.new == channel.old {
+			continue
+		}
+		if ch, err := b.session.State.Channel(channel.new); err != nil || ch.GuildID != server {
+			return fmt.Errorf("the %s channel has to be a channel on this server", channel.name)
+		}
+	}
+	return guildStore().Set(server, c)
+}
+
+// isAdmin reports whether the author of a message can manage the server it was sent in.
+func isAdmin(m *discordgo.MessageCreate, s *discordgo.Session) bool {
+	perms, err := s.UserChannelPermissions(m.Author.ID, m.ChannelID)
+	if err != nil {
+		logError(m, "error getting permissions for %s in %s: %s", m.Author.ID, m.ChannelID, err)
+		return false
+	}
+	return perms&(discordgo.PermissionManageServer|discordgo.PermissionAdministrator) != 0
+}
+
+// IsOwner reports whether user is the bot's owner.
+func (b *Bot) IsOwner(user string) bool {
+	owner := b.settings().owner
+	return owner != nil && owner.ID == user
+}
+
+// commandName returns the name of the command key is an alias for, or key if it isn't one.
+func commandName(key string) string {
+	if sp, ok := getCommand(key); ok {
+		return sp.name
+	}
+	return key
+}
+
+func showConfig(a args, m *discordgo.MessageCreate, s *discordgo.Session) string {
+	return describeConfig(guildConfig(m.GuildID))
+}
+
+func describeConfig(c guildconfig.Config) string {
+	disabled := "none"
+	if len(c.DisabledCommands) > 0 {
+		disabled = "`" + strings.Join(c.DisabledCommands, "`, `") + "`"
+	}
+	roles := "yes"
+	if !c.CreateRoles {
+		roles = "no"
+	}
+	prefix := "none"
+	if c.RolePrefix != "" {
+		prefix = "`" + c.RolePrefix + "`"
+	}
+	commandPrefix := "none (mention me)"
+	if c.Prefix != "" {
+		commandPrefix = "`" + c.Prefix + "`"
+	}
+	legacy := "yes"
+	if !c.LegacyAliases {
+		legacy = "no"
+	}
+	pvpChannel := "wherever it's asked for"
+	if c.PVPChannel != "" {
+		pvpChannel = "<#" + c.PVPChannel + ">"
+	}
+	unknown := "yes"
+	if !c.UnknownCommands {
+		unknown = "no"
+	}
+	announcements := "off"
+	if c.Announcements {
+		announcements = "none (set a channel to get them)"
+		if c.AnnounceChannel != "" {
+			announcements = "<#" + c.AnnounceChannel + ">"
+		}
+	}
+
+	message := "here's how I'm set up on this server:\n"
+	message += fmt.Sprintf("\n**command prefix**: %s", commandPrefix)
+	message += fmt.Sprintf("\n**`!rank`, `!vrank` and `!betterthan`**: %s", legacy)
+	message += fmt.Sprintf("\n**disabled commands**: %s", disabled)
+	message += fmt.Sprintf("\n**reply to commands I don't have**: %s", unknown)
+	message += fmt.Sprintf("\n**default league**: %s", c.DefaultLeague)
+	message += fmt.Sprintf("\n**create want roles**: %s", roles)
+	message += fmt.Sprintf("\n**want role prefix**: %s", prefix)
+	message += fmt.Sprintf("\n**pvp battle channel**: %s", pvpChannel)
+	message += fmt.Sprintf("\n**announcements from my owner**: %s", announcements)
+	message += fmt.Sprintf("\n**language**: %s", c.Language)
+	message += "\n\nto change them: `config prefix !wob`, `config legacy off`, `config disable want`, `config enable want`, `config unknown off`, `config league ultra`, `config roles off`, `config roleprefix want-`, `config pvp #pvp`, `config announce #announcements`, `config announcements off`, `config language en`"
+	return message
+}
@@ -0,0 +1,65 @@
+package bot
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// States of the gateway connection.
+const (
+	gatewayNeverConnected int32 = iota
+	gatewayConnected
+	gatewayDisconnected
+)
+
+// trackConnection keeps Connected up to date with the gateway connection, and counts reconnects.
+func (b *Bot) trackConnection() {
+	// discordgo sends Connect for resumes too, so Resumed isn't counted
+	b.session.AddHandler(func(s *discordgo.Session, e *discordgo.Connect) {
+		if atomic.SwapInt32(&b.connected, gatewayConnected) != gatewayNeverConnected {
+			gatewayReconnects.Inc()
+		}
+	})
+	b.session.AddHandler(func(s *discordgo.Session, e *discordgo.Disconnect) {
+		atomic.StoreInt32(&b.connected, gatewayDisconnected)
+	})
+}
+
+// Connected reports whether the bot is connected to the Discord gateway.
+func (b *Bot) Connected() bool {
+	return atomic.LoadInt32(&b.connected) == gatewayConnected
+}
+
+// PingServices checks that each configured service is reachable. The result has an entry for every configured
+// service, which is nil if it's reachable.
+func (b *Bot) PingServices(ctx context.Context) map[string]error {
+	pings := make(map[string]func(context.Context) error)
+	if b.settings().config.Rank.URL != "" {
+		pings["rank"] = ranks().Ping
+	}
+	if b.settings().config.Want.URL != "" {
+		pings["want"] = wants().Ping
+	}
+	if b.settings().config.PVP.URL != "" {
+		pings["pvp"] = pvps().Ping
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]error)
+	for name, ping := range pings {
+		wg.Add(1)
+		go func(name string, ping func(context.Context) error) {
+			defer wg.Done()
+			err := ping(ctx)
+			mu.Lock()
+			results[name] = err
+			mu.Unlock()
+		}(name, ping)
+	}
+	wg.Wait()
+	return results
+}
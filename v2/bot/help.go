@@ -6,15 +6,63 @@ import (
 	"github.com/bwmarrin/discordgo"
 )
 
-func runHelp(pieces []string, m *discordgo.MessageCreate, s *discordgo.Session) string {
+// legacyHelp is v1's help text, for servers still using `!rank help`.
+const legacyHelp = "usage: `!<command> <league?> <pokemon> <atk> <def> <sta>` (league is optional and defaults to `great`)\n\nCapitalization is irrelevant and `(`, `)` and `.` are stripped, so `Deoxys (Defense)` and `deoxys defense` are the same\n\n**Commands**\n`!rank` will tell you the rank of your IV spread\n`!vrank` (for `verbose rank`) will give you the values of each stat as well as the product, in case you want to double check the values against other, less Wobby, IV services\n`!betterthan` will tell you the odds of obtaining a higher rank"
+
+func runHelp(a args, m *discordgo.MessageCreate, s *discordgo.Session) string {
+	topic := a.Strings("command")
+	if len(topic) == 1 && topic[0] == "legacy" {
+		message := legacyHelp
+		if current != nil {
+			if owner := current.settings().owner; owner != nil {
+				message += "\n\nAny questions or concerns: ask " + owner.Mention()
+			}
+		}
+		return message
+	}
+	if len(topic) > 0 {
+		return commandHelp(topic)
+	}
+
 	message := "here is what you can ask me:\n"
 
-	for _, text := range help {
-		message = fmt.Sprintf("%s\n%s", message, text)
+	for _, key := range commandNames() {
+		if !featureEnabled(key) {
+			continue
+		}
+		message = fmt.Sprintf("%s\n**%s**: %s", message, key, helpText(key))
+	}
+	return message + "\n\n`help <command>` (or `<command> help`) for more about one"
+}
+
+// commandHelp is the detailed help for a command, or one of its subcommands, ie `pvp ultra`.
+func commandHelp(path []string) string {
+	sp, ok := getCommand(path[0])
+	if !ok || !featureEnabled(sp.name) {
+		return fmt.Sprintf("I don't have a `%s` command. `help` to see what I do have", path[0])
+	}
+	for _, name := range path[1:] {
+		sub := sp.subcommand(name)
+		if sub == nil {
+			return fmt.Sprintf("I don't have a `%s %s` command. `%s help` to see what I do have", sp.path(), name, sp.path())
+		}
+		sp = sub
 	}
-	return message
+	return sp.detail()
 }
 
 func init() {
-	registerCommand("help", runHelp, "print this message")
+	registerCommand(&commandSpec{
+		name:        "help",
+		aliases:     []string{"commands"},
+		description: "print this message",
+		examples:    []string{"help", "help rank", "help pvp ultra"},
+		args: []arg{{
+			name:        "command",
+			variadic:    true,
+			optional:    true,
+			description: "a command (and subcommand) to see more about, or `legacy` for how `!rank` works",
+		}},
+		run: runHelp,
+	})
 }
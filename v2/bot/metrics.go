@@ -0,0 +1,119 @@
+package bot
+
+import (
+	"github.com/Sigafoos/wobbotfet/backend"
+	"github.com/Sigafoos/wobbotfet/eventlog"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "wobbotfet"
+
+var (
+	commandsHandled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "commands_total",
+		Help:      "Commands handled, by command and outcome.",
+	}, []string{"command", "outcome"})
+
+	commandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "command_duration_seconds",
+		Help:      "How long it took to respond to a command.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"command"})
+
+	backendDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "backend_request_duration_seconds",
+		Help:      "How long requests to each service took, including retries.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"service"})
+
+	backendErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "backend_errors_total",
+		Help:      "Requests to each service that failed (no response, or a 5xx), by reason.",
+	}, []string{"service", "reason"})
+
+	gatewayReconnects = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "gateway_reconnects_total",
+		Help:      "Times the Discord gateway connection was re-established.",
+	})
+
+	activeConversations = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "active_conversations",
+		Help:      "PMs wobbotfet is waiting on a response to.",
+	}, func() float64 {
+		pmsMu.Lock()
+		defer pmsMu.Unlock()
+		return float64(len(activePMs))
+	})
+
+	battlingUsersDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "", "battling_users"),
+		"Users looking for PVP battles, by guild.",
+		[]string{"guild"}, nil,
+	)
+
+	guildsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "", "guilds"),
+		"Guilds wobbotfet is in.",
+		nil, nil,
+	)
+)
+
+func init() {
+	prometheus.MustRegister(commandsHandled, commandDuration, backendDuration, backendErrors, gatewayReconnects, activeConversations)
+}
+
+// stateCollector reports metrics read from the bot's state when scraped, rather than tracked as they change.
+type stateCollector struct {
+	b *Bot
+}
+
+func (c stateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- battlingUsersDesc
+	ch <- guildsDesc
+}
+
+func (c stateCollector) Collect(ch chan<- prometheus.Metric) {
+	if p := currentPVP(); p != nil {
+		for guild, users := range p.Battling() {
+			ch <- prometheus.MustNewConstMetric(battlingUsersDesc, prometheus.GaugeValue, float64(len(users)), guild)
+		}
+	}
+
+	c.b.session.State.RLock()
+	guilds := len(c.b.session.State.Guilds)
+	c.b.session.State.RUnlock()
+	ch <- prometheus.MustNewConstMetric(guildsDesc, prometheus.GaugeValue, float64(guilds))
+}
+
+// registerMetrics starts collecting the metrics that come from the session. Gateway reconnects are counted by
+// trackConnection.
+func (b *Bot) registerMetrics() {
+	prometheus.MustRegister(stateCollector{b})
+}
+
+// observeBackend is a backend.Observer that records the duration and failures of service calls.
+func observeBackend(r backend.Result) {
+	backendDuration.WithLabelValues(r.Service).Observe(r.Duration.Seconds())
+
+	if r.Err != nil || r.StatusCode >= 500 {
+		backendErrors.WithLabelValues(r.Service, backendStatus(r)).Inc()
+	}
+}
+
+// metricCommand is the command label for an event. Anyone can type anything, so commands that don't exist are lumped
+// together rather than each getting their own series.
+func metricCommand(command string) string {
+	if command == eventlog.CommandPMReply {
+		return command
+	}
+	if _, ok := getCommand(command); !ok {
+		return "unknown"
+	}
+	return command
+}
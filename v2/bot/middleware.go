@@ -0,0 +1,324 @@
+package bot
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"sync"
+
+	"github.com/Sigafoos/wobbotfet/eventlog"
+	"github.com/bwmarrin/discordgo"
+)
+
+// A call is a command being handled: what it was called as, and the event that will be logged for it.
+type call struct {
+	name  string
+	event *eventlog.Event
+	// registered is false for unknown commands and replies to questions, which aren't checked or limited.
+	registered bool
+	// interaction is set for slash commands, which are answered through it rather than in the channel.
+	interaction *interaction
+}
+
+// A middleware wraps a command with something that should happen whenever any command is used, so the commands
+// themselves don't have to do it.
+type middleware func(c *call, next command) command
+
+// middlewares are what every command goes through, outermost first. Anything that stops a command from running
+// should set the event's outcome and return what to tell the user.
+var middlewares = []middleware{
+	// outside everything, so a panic in any of them is caught
+	recoverPanics,
+	// outside logging, so the outcome's final
+	countCommands,
+	logCommands,
+	respond,
+	checkFeatures,
+	checkGuildConfig,
+	checkRequirements,
+	limitRate,
+	// last, so nothing that's ignored or refused looks like it's being worked on
+	showTyping,
+}
+
+// handle wraps a command in every middleware.
+func handle(c *call, f command) command {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		f = middlewares[i](c, f)
+	}
+	return f
+}
+
+// route works out what a message is: the reply to a question wobbotfet asked in a PM, a registered command or an
+// unknown one. It returns the call, the command to run and its arguments.
+func route(message string, m *discordgo.MessageCreate) (*call, command, []string) {
+	if next, ok := takePM(m.ChannelID); ok {
+		c := &call{name: eventlog.CommandPMReply, event: newEvent(m)}
+		c.event.Command = c.name
+		// we don't want to lowercase PM responses
+		pieces := strings.Split(message, " ")
+		c.event.Args = pieces
+		return c, next, pieces
+	}
+	return routeCommand(strings.Split(message, " "), m)
+}
+
+// routeCommand works out which command the first word is. The rest are left as they were typed, for the command to
+// parse.
+func routeCommand(pieces []string, m *discordgo.MessageCreate) (*call, command, []string) {
+	c := &call{name: strings.ToLower(pieces[0]), event: newEvent(m)}
+	c.event.Command = c.name
+	sp, ok := getCommand(c.name)
+	if !ok {
+		return c, unknownCommand(c), pieces
+	}
+
+	// aliases are logged (and limited, and turned off) as the command
+	c.name = sp.name
+	c.registered = true
+	c.event.Command = c.name
+	c.event.Args = make([]string, len(pieces)-1)
+	for i, piece := range pieces[1:] {
+		c.event.Args[i] = strings.ToLower(piece)
+	}
+	return c, sp.command(c), pieces[1:]
+}
+
+// unknownCommand is what's run for a command that doesn't exist. It's given every word, including the command. It
+// suggests what might have been meant, unless the server would rather it didn't reply at all.
+func unknownCommand(c *call) command {
+	return func(pieces []string, m *discordgo.MessageCreate, s *discordgo.Session) string {
+		c.event.Outcome = eventlog.OutcomeUnknown
+		if m.GuildID != "" && !guildConfig(m.GuildID).UnknownCommands {
+			return ""
+		}
+
+		message := fmt.Sprintf("I don't have a `%s` command", c.name)
+		if suggestions := suggestCommands(c.name, m.GuildID); len(suggestions) > 0 {
+			return message + ". did you mean " + orList(suggestions) + "?"
+		}
+		return message + ". `help` to see what I do have"
+	}
+}
+
+// countCommands records the metrics for every command.
+func countCommands(c *call, next command) command {
+	return func(pieces []string, m *discordgo.MessageCreate, s *discordgo.Session) string {
+		response := next(pieces, m, s)
+		countCommand(c)
+		return response
+	}
+}
+
+func countCommand(c *call) {
+	commandsHandled.WithLabelValues(metricCommand(c.name), c.event.Outcome).Inc()
+	commandDuration.WithLabelValues(metricCommand(c.name)).Observe(c.event.Latency / 1000)
+}
+
+// logCommands writes every command to the access log (and the stream), however it went. If it panics recoverPanics
+// logs it instead, once it knows that's the outcome.
+func logCommands(c *call, next command) command {
+	return func(pieces []string, m *discordgo.MessageCreate, s *discordgo.Session) string {
+		response := next(pieces, m, s)
+		logEvent(m.ID, c.event)
+		return response
+	}
+}
+
+// respond sends the response, split up if it's too long for one message. In a server it mentions who it's for. An
+// empty response means the command has already responded some other way.
+func respond(c *call, next command) command {
+	return func(pieces []string, m *discordgo.MessageCreate, s *discordgo.Session) string {
+		response := next(pieces, m, s)
+		if response == "" {
+			if c.interaction != nil {
+				// don't leave Discord saying wobbotfet's thinking
+				if err := c.interaction.deleteResponse(s); err != nil {
+					logError(m, "error deleting interaction response: %s", err)
+				}
+			}
+			return ""
+		}
+
+		for _, message := range splitResponse(response) {
+			if err := send(c, m, s, message); err != nil {
+				logError(m, "error sending message: %s", err)
+				c.event.Outcome = eventlog.OutcomeSendError
+				s.ChannelMessageSend(m.ChannelID, "sorry, something's gone wrong")
+				break
+			}
+		}
+		return ""
+	}
+}
+
+// send sends one message of a response: in the channel, mentioning who it's for in a server, or as a follow up to an
+// interaction.
+func send(c *call, m *discordgo.MessageCreate, s *discordgo.Session, message string) error {
+	if c.interaction != nil {
+		return c.interaction.followUp(s, message)
+	}
+	if m.GuildID != "" {
+		message = m.Author.Mention() + ": " + message
+	}
+	_, err := s.ChannelMessageSend(m.ChannelID, message)
+	return err
+}
+
+// recoverPanics stops a command that panics from taking wobbotfet down with it. The owner hears about it in the next
+// error digest. The middlewares it wraps don't get to finish, so it logs and counts the command and tells the user
+// itself.
+func recoverPanics(c *call, next command) command {
+	return func(pieces []string, m *discordgo.MessageCreate, s *discordgo.Session) string {
+		defer func() {
+			if r := recover(); r != nil {
+				c.event.Outcome = eventlog.OutcomePanic
+				publish(StreamPanic, m.GuildID, &Panic{
+					Channel: m.ChannelID,
+					User:    m.Author.ID,
+					Command: c.name,
+					Error:   fmt.Sprint(r),
+				})
+				reportPanic(m, r, debug.Stack())
+				logEvent(m.ID, c.event)
+				countCommand(c)
+				if err := send(c, m, s, "sorry, something's gone wrong"); err != nil {
+					logError(m, "error sending message: %s", err)
+				}
+			}
+		}()
+		return next(pieces, m, s)
+	}
+}
+
+// recoverEvent stops a panic handling something from Discord outside of a command (or in the middlewares that catch
+// them) from taking wobbotfet down with it. It has to be deferred.
+func recoverEvent(m *discordgo.MessageCreate) {
+	if r := recover(); r != nil {
+		reportPanic(m, r, debug.Stack())
+	}
+}
+
+// features are the commands that are turned off everywhere, by the config. They're replaced when it's reloaded.
+var features = struct {
+	sync.RWMutex
+	disabled map[string]bool
+}{disabled: make(map[string]bool)}
+
+// setupFeatures turns off the commands the config says to, except the ones that can't be.
+func setupFeatures(disabled []string) {
+	off := make(map[string]bool)
+	for _, command := range disabled {
+		// or the owner couldn't turn it back on without a restart
+		if alwaysEnabled[command] || command == "admin" {
+			logError(nil, "`%s` can't be disabled", command)
+			continue
+		}
+		off[command] = true
+	}
+
+	features.Lock()
+	features.disabled = off
+	features.Unlock()
+}
+
+// featureEnabled reports whether a command hasn't been turned off everywhere.
+func featureEnabled(command string) bool {
+	features.RLock()
+	defer features.RUnlock()
+	return !features.disabled[command]
+}
+
+// checkFeatures refuses the commands that are turned off everywhere.
+func checkFeatures(c *call, next command) command {
+	return func(pieces []string, m *discordgo.MessageCreate, s *discordgo.Session) string {
+		if c.registered && !featureEnabled(c.name) {
+			c.event.Outcome = eventlog.OutcomeDisabled
+			return fmt.Sprintf("`%s` is turned off right now", c.name)
+		}
+		return next(pieces, m, s)
+	}
+}
+
+// checkGuildConfig refuses the commands the server has turned off.
+func checkGuildConfig(c *call, next command) command {
+	return func(pieces []string, m *discordgo.MessageCreate, s *discordgo.Session) string {
+		if c.registered && !guildConfig(m.GuildID).Enabled(c.name) {
+			c.event.Outcome = eventlog.OutcomeDisabled
+			return fmt.Sprintf("`%s` is turned off on this server", c.name)
+		}
+		return next(pieces, m, s)
+	}
+}
+
+// A requirement is something that has to be true for a command to be used, like being in a server. It returns what
+// to tell the user if it isn't, or an empty string if it is.
+type requirement func(m *discordgo.MessageCreate, s *discordgo.Session) string
+
+func inGuild(m *discordgo.MessageCreate, s *discordgo.Session) string {
+	if m.GuildID == "" {
+		return "You can only do this in a server!"
+	}
+	return ""
+}
+
+func inPM(m *discordgo.MessageCreate, s *discordgo.Session) string {
+	if m.GuildID != "" {
+		return "PM me that"
+	}
+	return ""
+}
+
+// guildAdmin has to come after inGuild.
+func guildAdmin(m *discordgo.MessageCreate, s *discordgo.Session) string {
+	if !isAdmin(m, s) {
+		return "only people who can manage the server can do that"
+	}
+	return ""
+}
+
+func owner(m *discordgo.MessageCreate, s *discordgo.Session) string {
+	if current == nil || !current.IsOwner(m.Author.ID) {
+		return "only my owner can do that"
+	}
+	return ""
+}
+
+// checkRequirements refuses a command if any of its requirements aren't met.
+func checkRequirements(c *call, next command) command {
+	return func(pieces []string, m *discordgo.MessageCreate, s *discordgo.Session) string {
+		for _, r := range commandRequirements(c.name) {
+			if refusal := r(m, s); refusal != "" {
+				c.event.Outcome = eventlog.OutcomeForbidden
+				return refusal
+			}
+		}
+		return next(pieces, m, s)
+	}
+}
+
+// limitRate refuses a command if the user (or their server) has used too many.
+func limitRate(c *call, next command) command {
+	return func(pieces []string, m *discordgo.MessageCreate, s *discordgo.Session) string {
+		if c.registered {
+			if cooldown, limited := rateLimit(c.name, m, s); limited {
+				c.event.Outcome = eventlog.OutcomeRateLimited
+				return cooldown
+			}
+		}
+		return next(pieces, m, s)
+	}
+}
+
+// showTyping shows wobbotfet typing while the command runs. Discord already shows it thinking about slash commands.
+func showTyping(c *call, next command) command {
+	return func(pieces []string, m *discordgo.MessageCreate, s *discordgo.Session) string {
+		if c.interaction != nil {
+			return next(pieces, m, s)
+		}
+		if err := s.ChannelTyping(m.ChannelID); err != nil {
+			logError(m, "error sending typing call: %s", err)
+		}
+		return next(pieces, m, s)
+	}
+}
@@ -1,18 +1,17 @@
 package bot
 
 import (
-	"bytes"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
 	"log"
-	"net/http"
-	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Sigafoos/pvpservice/pvp"
+	"github.com/Sigafoos/wobbotfet/config"
+	"github.com/Sigafoos/wobbotfet/pvpclient"
 	"github.com/bwmarrin/discordgo"
 )
 
@@ -21,17 +20,40 @@ const (
 )
 
 var (
-	pvpURL = os.Getenv("PVP_URL")
-	p      *PVP
+	pvpClient *pvpclient.Client
+	// pvpHandler is who's registering and battling. Use currentPVP.
+	pvpHandler *PVP
 )
 
-func init() {
-	if pvpURL == "" {
-		log.Println("no PVP_URL specified; cannot run pvp command")
+// pvps returns the PVP service's client.
+func pvps() *pvpclient.Client {
+	servicesMu.RLock()
+	defer servicesMu.RUnlock()
+	return pvpClient
+}
+
+// currentPVP returns who's registering and battling, or nil if the PVP service has never been configured.
+func currentPVP() *PVP {
+	servicesMu.RLock()
+	defer servicesMu.RUnlock()
+	return pvpHandler
+}
+
+// setupPVP registers the pvp command, if the PVP service is configured.
+func setupPVP(c config.Service) {
+	if c.URL == "" {
 		return
 	}
-	p := newPVP()
-	registerCommand("pvp", p.Handle, "PVP friend tracking/battle announcing. `pvp help` for more details")
+	client := pvpclient.New(c.URL, serviceOptions(c)...)
+	servicesMu.Lock()
+	pvpClient = client
+	// keep anyone who's mid-registration or battling through a reload
+	if pvpHandler == nil {
+		pvpHandler = newPVP()
+	}
+	p := pvpHandler
+	servicesMu.Unlock()
+	registerCommand(p.spec())
 }
 
 type Answer int
@@ -48,6 +70,7 @@ type PVP struct {
 	registering map[string]pvp.Player
 	friendship  map[string]string
 	battling    map[string]map[string]*time.Timer
+	battlingMu  sync.Mutex
 }
 
 func newPVP() *PVP {
@@ -61,93 +84,114 @@ func newPVP() *PVP {
 	}
 }
 
-func (p *PVP) Handle(pieces []string, m *discordgo.MessageCreate, s *discordgo.Session) string {
-	// the session pointer is identical between messages. if we don't have one, or it's changed, use the current one
-	if s != p.session {
-		p.session = s
+// spec is the `pvp` command.
+func (p *PVP) spec() *commandSpec {
+	return &commandSpec{
+		name:        "pvp",
+		description: "PVP friend tracking/battle announcing. `pvp help` for more details",
+		subcommands: []*commandSpec{
+			{
+				name:        "register",
+				description: "sign up for PVP battles! I'll PM you to ask for your information.",
+				requires:    []requirement{inGuild},
+				run:         p.handle(p.HandleRegister),
+			},
+			{
+				name:        "list",
+				description: "list the players in your PVP servers",
+				requires:    []requirement{inPM},
+				run:         p.handle(p.HandleList),
+			},
+			{
+				name:        "ultra",
+				description: "say you're ultra friends with someone (I'll confirm with them first!)",
+				examples:    []string{"pvp ultra wobbofan", "pvp ultra todo"},
+				args:        []arg{{name: "ign", description: "their in-game name, or `todo` for who you still need to reach ultra with"}},
+				run:         p.handle(p.HandleUltra),
+			},
+			{
+				name:        "battle",
+				description: "let the server know you're looking for battles",
+				requires:    []requirement{inGuild},
+				examples:    []string{"pvp battle", "pvp battle 60", "pvp battle stop"},
+				args:        []arg{{name: "minutes", optional: true, description: fmt.Sprintf("how long for (defaults to %d), or `stop`", BattleTime)}},
+				run:         p.handle(p.HandleBattle),
+			},
+		},
 	}
+}
 
-	switch pieces[0] {
-	case "help":
-		return p.Help()
-	case "register":
-		if m.GuildID == "" {
-			return "You can only do this in a server!"
+// handle returns a handler that runs f with the current session.
+func (p *PVP) handle(f handler) handler {
+	return func(a args, m *discordgo.MessageCreate, s *discordgo.Session) string {
+		// the session pointer is identical between messages. if we don't have one, or it's changed, use the current one
+		if s != p.session {
+			p.session = s
 		}
+		return f(a, m, s)
+	}
+}
 
-		user := p.getUser(m.Author.ID)
-
-		if user == nil {
-			p.AskForIGN(m)
-			return "I'll PM you for details!"
-		}
+func (p *PVP) HandleRegister(a args, m *discordgo.MessageCreate, s *discordgo.Session) string {
+	user := p.getUser(m, m.Author.ID)
 
-		user.Server = m.GuildID
-		resp := p.RegisterPlayer(user)
-		if resp != "" {
-			return resp
-		}
-		return "You're all set! I'll PM you the friend codes."
+	if user == nil {
+		p.AskForIGN(m)
+		return "I'll PM you for details!"
+	}
 
-	case "list":
-		if m.GuildID != "" {
-			return "You have to ask this in a PM, sorry!"
-		}
+	user.Server = m.GuildID
+	resp := p.RegisterPlayer(m, user)
+	if resp != "" {
+		return resp
+	}
+	return "You're all set! I'll PM you the friend codes."
+}
 
-		return p.ListPlayers(m)
+func (p *PVP) HandleList(a args, m *discordgo.MessageCreate, s *discordgo.Session) string {
+	return p.ListPlayers(m)
+}
 
-	case "ultra":
-		user := p.getUser(m.Author.ID)
-		if user == nil {
-			return "you aren't registered!"
-		}
-		if len(user.Servers) == 0 {
-			return "you aren't in any servers!"
-		}
-		toFriend := p.NotUltraForPlayer(user)
+func (p *PVP) HandleUltra(a args, m *discordgo.MessageCreate, s *discordgo.Session) string {
+	user := p.getUser(m, m.Author.ID)
+	if user == nil {
+		return "you aren't registered!"
+	}
+	if len(user.Servers) == 0 {
+		return "you aren't in any servers!"
+	}
+	toFriend := p.NotUltraForPlayer(m, user)
 
-		if pieces[1] == "todo" {
-			if m.GuildID != "" {
-				return "you can only ask for your to-be-ultra list in a PM"
-			}
-			var todo []string
-			for _, player := range toFriend {
-				todo = append(todo, player.ToString())
-			}
-			return fmt.Sprintf("Here's who you still need to reach ultra with:\n\n%s\n\nTell me `pvp ultra (IGN)` to list yourself as ultra (I'll confirm with them first!)", strings.Join(todo, "\n"))
+	ign := a.String("ign")
+	if ign == "todo" {
+		if m.GuildID != "" {
+			return "you can only ask for your to-be-ultra list in a PM"
 		}
-
-		// are you able to friend this person?
-		for ign, player := range toFriend {
-			if strings.ToLower(ign) == pieces[1] {
-				return p.ConfirmFriendship(user, &player)
-			}
+		var todo []string
+		for _, player := range toFriend {
+			todo = append(todo, player.ToString())
 		}
-		return fmt.Sprintf("`%s` isn't someone you're in a PVP server with. Did you mean `pvp ultra todo` for the list of players you need to friend?", pieces[1])
+		return fmt.Sprintf("Here's who you still need to reach ultra with:\n\n%s\n\nTell me `pvp ultra (IGN)` to list yourself as ultra (I'll confirm with them first!)", strings.Join(todo, "\n"))
+	}
 
-	case "battle":
-		if m.GuildID == "" {
-			return "You can only do this in a server!"
+	// are you able to friend this person?
+	for name, player := range toFriend {
+		if strings.ToLower(name) == ign {
+			return p.ConfirmFriendship(user, &player)
 		}
-
-		return p.LookingForBattle(pieces[1:], m)
 	}
-	return fmt.Sprintf("I don't have a command `pvp %s`", pieces[0])
-}
-
-func (p *PVP) Help() string {
-	return "`pvp register`: sign up for PVP battles! I'll PM you to ask for your information."
+	return fmt.Sprintf("`%s` isn't someone you're in a PVP server with. Did you mean `pvp ultra todo` for the list of players you need to friend?", ign)
 }
 
-func (p *PVP) LookingForBattle(pieces []string, m *discordgo.MessageCreate) string {
+func (p *PVP) HandleBattle(a args, m *discordgo.MessageCreate, s *discordgo.Session) string {
 	length := BattleTime
-	if len(pieces) > 0 {
-		if pieces[0] == "0" || pieces[0] == "stop" || pieces[0] == "end" {
+	if minutes := a.String("minutes"); minutes != "" {
+		if minutes == "0" || minutes == "stop" || minutes == "end" {
 			return p.StopBattling(m)
 		}
-		l, err := strconv.Atoi(pieces[0])
+		l, err := strconv.Atoi(minutes)
 		if err != nil {
-			return fmt.Sprintf("`%s` doesn't seem to be a number", pieces[0])
+			return fmt.Sprintf("`%s` doesn't seem to be a number", minutes)
 		}
 		length = l
 	}
@@ -156,21 +200,36 @@ func (p *PVP) LookingForBattle(pieces []string, m *discordgo.MessageCreate) stri
 
 func (p *PVP) StartBattling(length int, m *discordgo.MessageCreate) string {
 	log.Printf("%v minute timer starting", length)
+	p.battlingMu.Lock()
+	defer p.battlingMu.Unlock()
 	if _, ok := p.battling[m.GuildID]; !ok {
 		p.battling[m.GuildID] = make(map[string]*time.Timer)
 	}
 
+	channel := m.ChannelID
+	if announce := guildConfig(m.GuildID).PVPChannel; announce != "" {
+		channel = announce
+	}
+
 	p.battling[m.GuildID][m.Author.ID] = time.AfterFunc(time.Duration(length)*time.Minute, func() {
-		p.session.ChannelMessageSend(m.ChannelID, "youre not battling anymore")
+		p.session.ChannelMessageSend(channel, m.Author.Mention()+": youre not battling anymore")
 		log.Println("timer up")
+		p.battlingMu.Lock()
 		delete(p.battling[m.GuildID], m.Author.ID)
+		p.battlingMu.Unlock()
 	})
 
-	// actually dont return a string, and have it ping the channel
+	if channel != m.ChannelID {
+		p.session.ChannelMessageSend(channel, fmt.Sprintf("%s is looking for battles for the next %v minutes!", m.Author.Mention(), length))
+		return fmt.Sprintf("I've let <#%s> know you're looking for battles for the next %v minutes!", channel, length)
+	}
 	return fmt.Sprintf("you're looking for battles for the next %v minutes!", length)
 }
 
 func (p *PVP) StopBattling(m *discordgo.MessageCreate) string {
+	p.battlingMu.Lock()
+	defer p.battlingMu.Unlock()
+
 	server, ok := p.battling[m.GuildID]
 	if !ok {
 		return "You aren't currently looking for battles!"
@@ -185,33 +244,24 @@ func (p *PVP) StopBattling(m *discordgo.MessageCreate) string {
 	return "You're no longer looking for battles"
 }
 
-func (p *PVP) GetPlayers(server string) []pvp.Player {
-	var players []pvp.Player
-
-	req, err := http.NewRequest(http.MethodGet, pvpURL+"/player/list?server="+server, nil)
-	if err != nil {
-		log.Printf("error creating player list request: %s", err.Error())
-		return players
-	}
-	req.Header.Add("Content-Type", applicationJSON)
-	req.Header.Add("Accept", applicationJSON)
-
-	response, err := client.Do(req)
-	if err != nil {
-		log.Printf("error executing player list: %s", err.Error())
-		return players
-	}
-	defer response.Body.Close()
+// Battling returns the IDs of the users looking for battles, by guild.
+func (p *PVP) Battling() map[string][]string {
+	p.battlingMu.Lock()
+	defer p.battlingMu.Unlock()
 
-	b, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		log.Printf("error reading player list body: %s", err.Error())
-		return players
+	battling := make(map[string][]string)
+	for guild, users := range p.battling {
+		for user := range users {
+			battling[guild] = append(battling[guild], user)
+		}
 	}
+	return battling
+}
 
-	err = json.Unmarshal(b, &players)
+func (p *PVP) GetPlayers(m *discordgo.MessageCreate, server string) []pvp.Player {
+	players, err := pvps().Players(requestContext(m), server)
 	if err != nil {
-		log.Printf("error unmarshaling player list: %s", err.Error())
+		logError(m, "error getting player list: %s", err)
 	}
 	return players
 }
@@ -228,7 +278,7 @@ func (p *PVP) deprecatedListPlayers(m *discordgo.MessageCreate, s *discordgo.Ses
 		guildName = guild.Name
 	}
 
-	players := p.GetPlayers(m.GuildID)
+	players := p.GetPlayers(m, m.GuildID)
 	if len(players) == 0 {
 		return guildName + " has no active players! Use `pvp register` to be the first!"
 	}
@@ -280,8 +330,8 @@ func (p *PVP) SaveFriendCode(pieces []string, m *discordgo.MessageCreate, s *dis
 	}
 	player.FriendCode = strings.Join(pieces, "")
 	p.registering[m.Author.ID] = player
-	expectPM(m.ChannelID, p.EggForUltra)
-	return "Do you use a lucky egg for ultra friendships?"
+	ask(s, m.ChannelID, "Do you use a lucky egg for ultra friendships?", yesNoCancel, p.EggForUltra)
+	return ""
 }
 
 func (p *PVP) EggForUltra(pieces []string, m *discordgo.MessageCreate, s *discordgo.Session) string {
@@ -291,8 +341,8 @@ func (p *PVP) EggForUltra(pieces []string, m *discordgo.MessageCreate, s *discor
 	}
 	response := p.parseAnswer(pieces)
 	if response == AnswerUnknown {
-		expectPM(m.ChannelID, p.EggForUltra)
-		return fmt.Sprintf("Sorry, I don't understand the answer '%s'. Please say 'yes' or 'no'.", strings.Join(pieces, " "))
+		ask(s, m.ChannelID, fmt.Sprintf("Sorry, I don't understand the answer '%s'. Please say 'yes' or 'no'.", strings.Join(pieces, " ")), yesNoCancel, p.EggForUltra)
+		return ""
 	}
 	if response == AnswerCancel {
 		delete(p.registering, m.Author.ID)
@@ -305,8 +355,8 @@ func (p *PVP) EggForUltra(pieces []string, m *discordgo.MessageCreate, s *discor
 		player.EggUltra = false
 	}
 
-	expectPM(m.ChannelID, p.ConfirmInfo)
-	return fmt.Sprintf("Does this look right?\n\nIn-game name: %s\nFriend code: %s\nEgg for ultra: %v", player.IGN, player.FriendCode, player.EggUltra)
+	ask(s, m.ChannelID, fmt.Sprintf("Does this look right?\n\nIn-game name: %s\nFriend code: %s\nEgg for ultra: %v", player.IGN, player.FriendCode, player.EggUltra), yesNoCancel, p.ConfirmInfo)
+	return ""
 }
 
 func (p *PVP) ConfirmInfo(pieces []string, m *discordgo.MessageCreate, s *discordgo.Session) string {
@@ -318,12 +368,14 @@ func (p *PVP) ConfirmInfo(pieces []string, m *discordgo.MessageCreate, s *discor
 	if response == AnswerYes {
 		// either way they'll need to start over
 		delete(p.registering, m.Author.ID)
-		success := p.CreatePlayer(player)
-		// this is also where you should fix this shitty solution
-		if success != "" {
-			return success
+		err := pvps().CreatePlayer(requestContext(m), &player)
+		if errors.Is(err, pvpclient.ErrConflict) {
+			return "Wait, you're registered already!"
 		}
-		return p.RegisterPlayer(&player)
+		if err != nil {
+			return errorMessage(m, err)
+		}
+		return p.RegisterPlayer(m, &player)
 	}
 	if response == AnswerNo {
 		expectPM(m.ChannelID, p.AskForFriendCode)
@@ -334,98 +386,29 @@ func (p *PVP) ConfirmInfo(pieces []string, m *discordgo.MessageCreate, s *discor
 		return "Okay, start again when you're ready"
 	}
 
-	expectPM(m.ChannelID, p.ConfirmInfo)
-	return fmt.Sprintf("Sorry, I don't understand the answer '%s'. Please say 'yes' or 'no'.", strings.Join(pieces, " "))
-}
-
-func (p *PVP) RegisterPlayer(player *pvp.Player) string {
-	resp := p.RegisterOnServer(player)
-	if resp != "" {
-		return resp
-	}
-
-	return p.pmFriendList(player)
-}
-
-// TODO returning a string sucks
-func (p *PVP) CreatePlayer(player pvp.Player) string {
-	b, err := json.Marshal(&player)
-	if err != nil {
-		log.Printf("error marshalling player json: %s", err.Error())
-		return "sorry, something's gone wrong"
-	}
-	req, err := http.NewRequest(http.MethodPost, pvpURL+"/player", bytes.NewReader(b))
-	if err != nil {
-		log.Printf("error creating player request: %s", err.Error())
-		return "sorry, something's gone wrong"
-	}
-	req.Header.Add("Content-Type", applicationJSON)
-	req.Header.Add("Accept", applicationJSON)
-
-	response, err := client.Do(req)
-	if err != nil {
-		log.Printf("error performing player register request: %s", err.Error())
-		return "sorry, something's gone wrong"
-	}
-	if response.Body != nil {
-		defer response.Body.Close()
-	}
-
-	if response.StatusCode == http.StatusConflict {
-		return "Wait, you're registered already!"
-	}
-
-	if response.StatusCode != http.StatusCreated {
-		log.Printf("error registering user: got %v\n", response.StatusCode)
-		return "uh oh, something went wrong"
-	}
-
+	ask(s, m.ChannelID, fmt.Sprintf("Sorry, I don't understand the answer '%s'. Please say 'yes' or 'no'.", strings.Join(pieces, " ")), yesNoCancel, p.ConfirmInfo)
 	return ""
 }
 
-func (p *PVP) RegisterOnServer(player *pvp.Player) string {
-	// this is actually sending along all of a player's data when we really just need the server and user id
-	b, err := json.Marshal(&player)
-	if err != nil {
-		log.Printf("error marshalling register json: %s", err.Error())
-		return "sorry, something's gone wrong"
-	}
-	req, err := http.NewRequest(http.MethodPost, pvpURL+"/register", bytes.NewReader(b))
-	if err != nil {
-		log.Printf("error creating register request: %s", err.Error())
-		return "sorry, something's gone wrong"
-	}
-	req.Header.Add("Content-Type", applicationJSON)
-	req.Header.Add("Accept", applicationJSON)
-
-	response, err := client.Do(req)
-	if err != nil {
-		log.Printf("error performing register request: %s", err.Error())
-		return "sorry, something's gone wrong"
-	}
-	if response.Body != nil {
-		defer response.Body.Close()
-	}
-
-	if response.StatusCode == http.StatusConflict {
+func (p *PVP) RegisterPlayer(m *discordgo.MessageCreate, player *pvp.Player) string {
+	err := pvps().Register(requestContext(m), player)
+	if errors.Is(err, pvpclient.ErrConflict) {
 		return "Wait, you're registered already!"
 	}
-
-	if response.StatusCode != http.StatusCreated {
-		log.Printf("error registering user: got %v\n", response.StatusCode)
-		return "uh oh, something went wrong"
+	if err != nil {
+		return errorMessage(m, err)
 	}
 
-	return ""
+	return p.pmFriendList(m, player)
 }
 
-func (p *PVP) pmFriendList(player *pvp.Player) string {
-	players := p.GetPlayers(player.Server)
+func (p *PVP) pmFriendList(m *discordgo.MessageCreate, player *pvp.Player) string {
+	players := p.GetPlayers(m, player.Server)
 
 	var guildName string
 	guild, err := p.session.Guild(player.Server)
 	if err != nil {
-		log.Printf("error getting guild id for %s: %s", player.Server, err.Error())
+		logError(m, "error getting guild id for %s: %s", player.Server, err)
 		guildName = "a server you're in"
 	} else {
 		guildName = guild.Name
@@ -453,44 +436,19 @@ func (p *PVP) pmFriendList(player *pvp.Player) string {
 	return ""
 }
 
-func (p *PVP) getUser(id string) *pvp.Player {
-	req, err := http.NewRequest(http.MethodGet, pvpURL+"/player?id="+id, nil)
-	if err != nil {
-		log.Printf("error getting player request: %s", err.Error())
-		return nil
-	}
-	req.Header.Add("Content-Type", applicationJSON)
-	req.Header.Add("Accept", applicationJSON)
-
-	response, err := client.Do(req)
-	if err != nil {
-		log.Printf("error performing player list request: %s", err.Error())
-		return nil
-	}
-	if response.Body != nil {
-		defer response.Body.Close()
-	}
-	var user pvp.Player
-	b, err := ioutil.ReadAll(response.Body)
-
-	if len(b) == 0 {
-		return nil
-	}
-
+func (p *PVP) getUser(m *discordgo.MessageCreate, id string) *pvp.Player {
+	user, err := pvps().Player(requestContext(m), id)
 	if err != nil {
-		log.Printf("error reading player list body: %s", err.Error())
-		return nil
-	}
-	err = json.Unmarshal(b, &user)
-	if err != nil {
-		log.Printf("error decoding player list body: %s (%s)", err.Error(), string(b))
+		if !errors.Is(err, pvpclient.ErrNotFound) {
+			logError(m, "error getting player: %s", err)
+		}
 		return nil
 	}
-	return &user
+	return user
 }
 
 func (p *PVP) ListPlayers(m *discordgo.MessageCreate) string {
-	user := p.getUser(m.Author.ID)
+	user := p.getUser(m, m.Author.ID)
 	if user == nil {
 		return "you aren't registered!"
 	}
@@ -510,7 +468,7 @@ func (p *PVP) ListPlayers(m *discordgo.MessageCreate) string {
 		}
 
 		list += fmt.Sprintf("**%s**\n", guildName)
-		for _, player := range p.GetPlayers(server) {
+		for _, player := range p.GetPlayers(m, server) {
 			list += player.ToString() + "\n"
 		}
 		list += "\n"
@@ -518,15 +476,15 @@ func (p *PVP) ListPlayers(m *discordgo.MessageCreate) string {
 	return list
 }
 
-func (p *PVP) NotUltraForPlayer(user *pvp.Player) map[string]pvp.Player {
+func (p *PVP) NotUltraForPlayer(m *discordgo.MessageCreate, user *pvp.Player) map[string]pvp.Player {
 	friends := make(map[string]bool)
 	toFriend := make(map[string]pvp.Player)
-	for _, friend := range p.getFriends(user.ID) {
+	for _, friend := range p.getFriends(m, user.ID) {
 		friends[friend.IGN] = true
 	}
 
 	for _, server := range user.Servers {
-		for _, player := range p.GetPlayers(server) {
+		for _, player := range p.GetPlayers(m, server) {
 			if player.ID == user.ID {
 				continue
 			}
@@ -548,9 +506,8 @@ func (p *PVP) ConfirmFriendship(user, friend *pvp.Player) string {
 	log.Println("about to start confirm OM")
 	pm := startPM(p.session, friend.ID)
 	if pm != nil {
-		expectPM(pm.ID, p.AddFriend)
 		message := fmt.Sprintf("Hi! %s (%s) says you're ultra friends. Can you confirm this?", user.IGN, user.Username)
-		p.session.ChannelMessageSend(pm.ID, message)
+		ask(p.session, pm.ID, message, yesNo, p.AddFriend)
 		return "Okay, I'll confirm with them that you're ultra friends"
 	}
 	delete(p.friendship, friend.ID)
@@ -573,42 +530,19 @@ func (p *PVP) AddFriend(pieces []string, m *discordgo.MessageCreate, s *discordg
 			Friend: m.Author.ID,
 		}
 
-		b, err := json.Marshal(&friendship)
-		if err != nil {
-			log.Printf("error marshalling friendship json: %s", err.Error())
-			return "sorry, something's gone wrong"
-		}
-		req, err := http.NewRequest(http.MethodPost, pvpURL+"/player/friend", bytes.NewReader(b))
-		if err != nil {
-			log.Printf("error creating friendship request: %s", err.Error())
-			return "sorry, something's gone wrong"
-		}
-		req.Header.Add("Content-Type", applicationJSON)
-		req.Header.Add("Accept", applicationJSON)
-
-		response, err := client.Do(req)
-		if err != nil {
-			log.Printf("error performing friendship request: %s", err.Error())
-			return "sorry, something's gone wrong"
-		}
-		if response.Body != nil {
-			defer response.Body.Close()
-		}
-
-		if response.StatusCode == http.StatusConflict {
+		err := pvps().AddFriend(requestContext(m), &friendship)
+		if errors.Is(err, pvpclient.ErrConflict) {
 			return "You two seem to be friends already. This is weird."
 		}
-
-		if response.StatusCode != http.StatusCreated {
-			log.Printf("error registering friendship: got %v\n", response.StatusCode)
-			return "uh oh, something went wrong"
+		if err != nil {
+			return errorMessage(m, err)
 		}
 
 		// if there's an error PMing it's not the end of the world
 		log.Println("about to start has confirmed OM")
 		pm := startPM(s, id)
 		if pm != nil {
-			user := p.getUser(m.Author.ID)
+			user := p.getUser(m, m.Author.ID)
 			message := fmt.Sprintf("Hi! %s (%s) has confirmed your friendship", user.IGN, user.Username)
 			p.session.ChannelMessageSend(pm.ID, message)
 		}
@@ -623,7 +557,7 @@ func (p *PVP) AddFriend(pieces []string, m *discordgo.MessageCreate, s *discordg
 			log.Println("about to start mo OM")
 			pm := startPM(s, id)
 			if pm != nil {
-				user := p.getUser(m.Author.ID)
+				user := p.getUser(m, m.Author.ID)
 				message := fmt.Sprintf("Hi! %s (%s) says you're aren't actually ultra friends. Please confer with them and try again.", user.IGN, user.Username)
 				p.session.ChannelMessageSend(pm.ID, message)
 			}
@@ -632,41 +566,14 @@ func (p *PVP) AddFriend(pieces []string, m *discordgo.MessageCreate, s *discordg
 		return "Sorry for bothering you! I've let them know."
 	}
 
-	expectPM(m.ChannelID, p.AddFriend)
-	return fmt.Sprintf("Sorry, I don't understand the answer '%s'. Please say 'yes' or 'no'.", strings.Join(pieces, " "))
+	ask(s, m.ChannelID, fmt.Sprintf("Sorry, I don't understand the answer '%s'. Please say 'yes' or 'no'.", strings.Join(pieces, " ")), yesNo, p.AddFriend)
+	return ""
 }
 
-func (p *PVP) getFriends(ID string) []pvp.Player {
-	var friends []pvp.Player
-	req, err := http.NewRequest(http.MethodGet, pvpURL+"/player/friend?id="+ID, nil)
-	if err != nil {
-		log.Printf("error getting player friend request: %s", err.Error())
-		return friends
-	}
-	req.Header.Add("Content-Type", applicationJSON)
-	req.Header.Add("Accept", applicationJSON)
-
-	response, err := client.Do(req)
-	if err != nil {
-		log.Printf("error performing friend list request: %s", err.Error())
-		return friends
-	}
-	if response.Body != nil {
-		defer response.Body.Close()
-	}
-	b, err := ioutil.ReadAll(response.Body)
-
-	if len(b) == 0 {
-		return friends
-	}
-
-	if err != nil {
-		log.Printf("error reading friend list body: %s", err.Error())
-		return friends
-	}
-	err = json.Unmarshal(b, &friends)
+func (p *PVP) getFriends(m *discordgo.MessageCreate, ID string) []pvp.Player {
+	friends, err := pvps().Friends(requestContext(m), ID)
 	if err != nil {
-		log.Printf("error decoding player list body: %s (%s)", err.Error(), string(b))
+		logError(m, "error getting friend list: %s", err)
 	}
 	return friends
 }
@@ -0,0 +1,274 @@
+package bot
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// questionTimeout is how long wobbotfet waits on the answer to a question before turning its buttons off and giving
+// up.
+const questionTimeout = 15 * time.Minute
+
+// Types of message component. discordgo doesn't know about them.
+const (
+	componentRow    = 1
+	componentButton = 2
+)
+
+// Button styles.
+const (
+	buttonSecondary = 2
+	buttonSuccess   = 3
+	buttonDanger    = 4
+)
+
+// answerPrefix starts the custom ID of an answer button, ie `answer:yes`. What follows is the answer, as it would be
+// typed.
+const answerPrefix = "answer:"
+
+// A button is an answer to a question that can be clicked instead of typed. If the buttons can't be sent, it's a
+// reaction instead.
+type button struct {
+	label  string
+	answer string
+	style  int
+	emoji  string
+}
+
+var (
+	// yesNoCancel are the answers to a question that's part of something that can be given up on, ie registering.
+	yesNoCancel = []button{
+		{label: "Yes", answer: "yes", style: buttonSuccess, emoji: "✅"},
+		{label: "No", answer: "no", style: buttonDanger, emoji: "❌"},
+		{label: "Cancel", answer: "cancel", style: buttonSecondary, emoji: "🚫"},
+	}
+	yesNo = yesNoCancel[:2]
+)
+
+type messageComponent struct {
+	Type       int                `json:"type"`
+	Style      int                `json:"style,omitempty"`
+	Label      string             `json:"label,omitempty"`
+	CustomID   string             `json:"custom_id,omitempty"`
+	Disabled   bool               `json:"disabled,omitempty"`
+	Components []messageComponent `json:"components,omitempty"`
+}
+
+// buttonRow returns the buttons as the components of a message.
+func buttonRow(buttons []button, disabled bool) []messageComponent {
+	row := messageComponent{Type: componentRow}
+	for _, b := range buttons {
+		row.Components = append(row.Components, messageComponent{
+			Type:     componentButton,
+			Style:    b.style,
+			Label:    b.label,
+			CustomID: answerPrefix + b.answer,
+			Disabled: disabled,
+		})
+	}
+	return []messageComponent{row}
+}
+
+// A question is a message in a PM with buttons for the answers. Only the last question asked in a PM is open.
+type question struct {
+	session *discordgo.Session
+	channel string
+	message string
+	buttons []button
+	// reactions is whether the answers are reactions, because the buttons couldn't be sent.
+	reactions bool
+	timer     *time.Timer
+}
+
+var (
+	// questions are the open questions, by PM channel
+	questions   = make(map[string]*question)
+	questionsMu sync.Mutex
+)
+
+// ask asks a question in a PM, with a button for each answer, and waits on the answer. Whether it's clicked or typed,
+// next gets it as if it was typed. If the buttons can't be sent the question's sent with a reaction for each answer
+// instead.
+func ask(s *discordgo.Session, channel, text string, buttons []button, next command) {
+	expectPM(channel, next)
+
+	url := slashAPI + "channels/" + channel + "/messages"
+	body, err := s.RequestWithBucketID("POST", url, map[string]interface{}{
+		"content":    text,
+		"components": buttonRow(buttons, false),
+	}, url)
+	sent := &discordgo.Message{}
+	if err == nil {
+		err = json.Unmarshal(body, sent)
+	}
+	q := &question{session: s, channel: channel, message: sent.ID, buttons: buttons}
+	if err != nil {
+		logError(nil, "error asking a question with buttons: %s", err)
+		sent, err = s.ChannelMessageSend(channel, text)
+		if err != nil {
+			logError(nil, "error asking a question: %s", err)
+			return
+		}
+		q.message = sent.ID
+		q.reactions = true
+		for _, b := range buttons {
+			if err := s.MessageReactionAdd(channel, sent.ID, b.emoji); err != nil {
+				logError(nil, "error adding a reaction to a question: %s", err)
+			}
+		}
+	}
+
+	q.timer = time.AfterFunc(questionTimeout, q.expire)
+	questionsMu.Lock()
+	previous := questions[channel]
+	questions[channel] = q
+	questionsMu.Unlock()
+	if previous != nil {
+		previous.close()
+	}
+}
+
+// closeQuestion turns off the buttons (or reactions) on the question open in a PM, if there is one, since it's been answered (or
+// isn't being waited on any more).
+func closeQuestion(channel string) {
+	questionsMu.Lock()
+	q, ok := questions[channel]
+	delete(questions, channel)
+	questionsMu.Unlock()
+
+	if ok {
+		q.close()
+	}
+}
+
+// isOpen reports whether message is the question open in a PM.
+func isOpen(channel, message string) bool {
+	questionsMu.Lock()
+	defer questionsMu.Unlock()
+
+	q, ok := questions[channel]
+	return ok && q.message == message
+}
+
+// close turns off the question's buttons, or takes wobbotfet's reactions off it. Bots can't remove anyone else's
+// reactions in a PM, so those stay.
+func (q *question) close() {
+	q.timer.Stop()
+	if q.reactions {
+		for _, b := range q.buttons {
+			if err := q.session.MessageReactionRemove(q.channel, q.message, b.emoji, "@me"); err != nil {
+				logError(nil, "error removing a reaction from a question: %s", err)
+			}
+		}
+		return
+	}
+	url := slashAPI + "channels/" + q.channel + "/messages/" + q.message
+	if _, err := q.session.RequestWithBucketID("PATCH", url, map[string]interface{}{"components": buttonRow(q.buttons, true)}, url); err != nil {
+		logError(nil, "error turning off a question's buttons: %s", err)
+	}
+}
+
+// expire gives up on the question, if it's still open.
+func (q *question) expire() {
+	questionsMu.Lock()
+	open := questions[q.channel] == q
+	if open {
+		delete(questions, q.channel)
+	}
+	questionsMu.Unlock()
+
+	if open {
+		q.close()
+		expirePM(q.channel)
+	}
+}
+
+// readButton answers a question with the button that was clicked, as if the answer had been typed.
+func readButton(s *discordgo.Session, i *interaction) {
+	defer recoverEvent(nil)
+
+	answer := strings.TrimPrefix(i.Data.CustomID, answerPrefix)
+	if answer == i.Data.CustomID || i.Message == nil {
+		return
+	}
+	m := i.message([]string{answer})
+	if m.Author == nil {
+		return
+	}
+
+	callback := slashAPI + "interactions/" + i.ID + "/" + i.Token + "/callback"
+	if !isOpen(i.ChannelID, i.Message.ID) {
+		// it's been answered, or wobbotfet's restarted since it asked, so the buttons should've been turned off
+		response := map[string]interface{}{
+			"type": responseUpdate,
+			"data": map[string]interface{}{"components": []messageComponent{}},
+		}
+		if _, err := s.RequestWithBucketID("POST", callback, response, callback); err != nil {
+			logError(m, "error removing buttons: %s", err)
+		}
+		return
+	}
+
+	// the buttons are turned off when the answer's taken
+	if _, err := s.RequestWithBucketID("POST", callback, map[string]int{"type": responseDeferredUpdate}, callback); err != nil {
+		logError(m, "error responding to button: %s", err)
+		return
+	}
+	c, f, args := route(answer, m)
+	handle(c, f)(args, m, s)
+}
+
+// readReaction answers a question with the reaction that was added, if the question's using reactions, as if the
+// answer had been typed.
+func readReaction(s *discordgo.Session, r *discordgo.MessageReactionAdd) {
+	defer recoverEvent(nil)
+
+	// ignore wobbotfet adding the reactions in the first place
+	if r.UserID == s.State.User.ID {
+		return
+	}
+	answer, ok := reactionAnswer(r.ChannelID, r.MessageID, r.Emoji.Name)
+	if !ok {
+		return
+	}
+
+	author := &discordgo.User{ID: r.UserID}
+	if channel, err := s.State.Channel(r.ChannelID); err == nil {
+		for _, u := range channel.Recipients {
+			if u.ID == r.UserID {
+				author = u
+			}
+		}
+	}
+	m := &discordgo.MessageCreate{Message: &discordgo.Message{
+		ID:        r.MessageID,
+		ChannelID: r.ChannelID,
+		GuildID:   r.GuildID,
+		Author:    author,
+		Content:   answer,
+	}}
+	c, f, args := route(answer, m)
+	handle(c, f)(args, m, s)
+}
+
+// reactionAnswer returns the answer an emoji is for, if message is the question open in a PM and its answers are
+// reactions.
+func reactionAnswer(channel, message, emoji string) (string, bool) {
+	questionsMu.Lock()
+	defer questionsMu.Unlock()
+
+	q, ok := questions[channel]
+	if !ok || !q.reactions || q.message != message {
+		return "", false
+	}
+	for _, b := range q.buttons {
+		if b.emoji == emoji {
+			return b.answer, true
+		}
+	}
+	return "", false
+}
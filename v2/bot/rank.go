@@ -1,117 +1,131 @@
 package bot
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
-	"log"
 	"math"
-	"net/http"
-	"net/url"
-	"os"
-	"strconv"
+	"sort"
 	"strings"
 
-	"github.com/Sigafoos/iv/model"
+	"github.com/Sigafoos/wobbotfet/config"
+	"github.com/Sigafoos/wobbotfet/guildconfig"
+	"github.com/Sigafoos/wobbotfet/rankclient"
 	"github.com/bwmarrin/discordgo"
 )
 
-var (
-	rankBase = os.Getenv("RANK_URL")
-	rankURL  = rankBase + "/iv?pokemon=%s&ivs=%v/%v/%v&league=%s"
-)
+var rankClient *rankclient.Client
+
+// ranks returns the ranking service's client.
+func ranks() *rankclient.Client {
+	servicesMu.RLock()
+	defer servicesMu.RUnlock()
+	return rankClient
+}
 
-func init() {
-	if rankBase == "" {
-		log.Println("no RANK_URL specified; cannot run rank command")
+// setupRank registers the rank commands, if the ranking service is configured.
+func setupRank(c config.Service) {
+	if c.URL == "" {
 		return
 	}
-	registerCommand("rank", rank, "`rank azumarill 4 1 3` to see the rank (out of 4096 possible combinations) of your IV spread's stat product")
-	registerCommand("vrank", verboseRank, "`vrank azumarill 4 1 3` to get the same rank as `rank` with the values used in its calculation")
-	registerCommand("betterthan", betterthanRank, "`betterthan azumarill 4 1 3` to see the chances of getting a better Pokemon from a variety of situations")
+	client := rankclient.New(c.URL, serviceOptions(c)...)
+	servicesMu.Lock()
+	rankClient = client
+	servicesMu.Unlock()
+	registerCommand(&commandSpec{
+		name:        "rank",
+		description: "see the rank (out of 4096 possible combinations) of your IV spread's stat product",
+		examples:    []string{"rank azumarill 4 1 3", "rank ultra deoxys defense 4/1/3", "rank azumarill 15 15 15 raid"},
+		args:        rankArgs(),
+		rewrite:     splitIVs,
+		run:         rank,
+	})
+	registerCommand(&commandSpec{
+		name:        "vrank",
+		description: "get the same rank as `rank` with the values used in its calculation",
+		examples:    []string{"vrank azumarill 4 1 3", "vrank ultra giratina 15 14 15"},
+		args:        rankArgs(),
+		rewrite:     splitIVs,
+		run:         verboseRank,
+	})
+	registerCommand(&commandSpec{
+		name:        "betterthan",
+		description: "see the chances of getting a better Pokemon from a variety of situations",
+		examples:    []string{"betterthan azumarill 4 1 3", "betterthan azumarill 4 1 3 hatched"},
+		args:        rankArgs(),
+		rewrite:     splitIVs,
+		run:         betterthanRank,
+	})
 }
 
-func rank(pieces []string, m *discordgo.MessageCreate, s *discordgo.Session) string {
-	return getRank(pieces, m, false, false)
-}
+// rankArgs are the arguments to the rank commands, ie `rank great azumarill 4 1 3 hatched`.
+func rankArgs() []arg {
+	floors := make([]string, 0, len(FloorMap))
+	for floor := range FloorMap {
+		floors = append(floors, floor)
+	}
+	sort.Strings(floors)
 
-func verboseRank(pieces []string, m *discordgo.MessageCreate, s *discordgo.Session) string {
-	return getRank(pieces, m, true, false)
+	return []arg{
+		{name: "league", choices: guildconfig.Leagues, optional: true, description: "the league (defaults to the server's)"},
+		{name: "pokemon", variadic: true, description: "the Pokemon"},
+		{name: "atk", kind: kindInt, max: 15, description: "its attack IV"},
+		{name: "def", kind: kindInt, max: 15, description: "its defense IV"},
+		{name: "hp", kind: kindInt, max: 15, description: "its HP IV"},
+		{name: "floor", choices: floors, optional: true, description: "how you got it, if that means its IVs have a minimum"},
+	}
 }
 
-func betterthanRank(pieces []string, m *discordgo.MessageCreate, s *discordgo.Session) string {
-	return getRank(pieces, m, false, true)
+// splitIVs turns `rank azumarill 4/1/3` into `rank azumarill 4 1 3`, including when there's a floor after them.
+func splitIVs(p []string) []string {
+	words := make([]string, 0, len(p)+2)
+	for _, word := range p {
+		if strings.Count(word, "/") == 2 {
+			words = append(words, strings.Split(word, "/")...)
+			continue
+		}
+		words = append(words, word)
+	}
+	return words
 }
 
-func getRank(pieces []string, m *discordgo.MessageCreate, verbose bool, betterthan bool) string {
-	query, err := parseQuery(pieces)
-	if err != nil {
-		return fmt.Sprintf("`%s` isn't a valid rank command (did you pass `4/1/3` instead of `4 1 3`?)", strings.Join(pieces, " "))
-	}
+func rank(a args, m *discordgo.MessageCreate, s *discordgo.Session) string {
+	return getRank(a, m, false, false)
+}
 
-	cmd := "rank"
-	if verbose {
-		cmd = "vrank"
-	} else if betterthan {
-		cmd = "betterthan"
-	}
+func verboseRank(a args, m *discordgo.MessageCreate, s *discordgo.Session) string {
+	return getRank(a, m, true, false)
+}
 
-	access.Printf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", m.GuildID, m.ChannelID, m.Author.String(), cmd, query.League, query.Pokemon, query.Atk, query.Def, query.HP)
+func betterthanRank(a args, m *discordgo.MessageCreate, s *discordgo.Session) string {
+	return getRank(a, m, false, true)
+}
 
-	atk, def, hp, err := parseIVs(query.Atk, query.Def, query.HP)
-	if err != nil {
-		return err.Error()
+func getRank(a args, m *discordgo.MessageCreate, verbose bool, betterthan bool) string {
+	league := a.String("league")
+	if league == "" {
+		league = guildConfig(m.GuildID).DefaultLeague
 	}
-
-	if query.League == "master" {
+	pokemon := a.String("pokemon")
+	// master isn't a league choice, so it ends up in front of the Pokemon
+	if strings.HasPrefix(pokemon, "master ") {
 		return "sorry, only `great` and `ultra` are supported"
 	}
-
-	parsedURL := fmt.Sprintf(rankURL, url.QueryEscape(query.Pokemon), atk, def, hp, query.League)
-	req, err := http.NewRequest(http.MethodGet, parsedURL, nil)
-	if err != nil {
-		log.Println(err)
-		return "sorry, something's gone wrong"
+	spread, err := ranks().Rank(requestContext(m), pokemon, a.Int("atk"), a.Int("def"), a.Int("hp"), league)
+	if errors.Is(err, rankclient.ErrNotFound) {
+		return fmt.Sprintf("`%s` isn't a valid Pokemon", pokemon)
 	}
-	req.Header.Add("Accept", "application/json")
-	resp, err := client.Do(req)
 	if err != nil {
-		log.Println(err)
-		return "sorry, something's gone wrong"
-	}
-
-	defer resp.Body.Close()
-	if resp.StatusCode == http.StatusNotFound {
-		return fmt.Sprintf("`%s` isn't a valid Pokemon", query.Pokemon)
-	}
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("got non-200 (%v) on %s\n", resp.StatusCode, parsedURL)
-		return "sorry, something's gone wrong"
-	}
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		log.Println(err)
-		return "sorry, something's gone wrong"
-	}
-
-	var spread model.Spread
-	err = json.Unmarshal(body, &spread)
-	if err != nil {
-		log.Println(err)
-		return "sorry, something's gone wrong"
+		return errorMessage(m, err)
 	}
 
 	rank := *spread.Ranks.All
-	if query.Floor != "" {
-		switch query.Floor {
-		// all others can still be obtained in the wild so who cares
-		case FloorHatched:
-			rank = *spread.Ranks.Hatched
-		}
+	switch FloorMap[a.String("floor")] {
+	// all others can still be obtained in the wild so who cares
+	case FloorHatched:
+		rank = *spread.Ranks.Hatched
 	}
 
-	message := fmt.Sprintf("your %s is rank %v (%v%%)", query.Pokemon, rank, (math.Trunc(spread.Percentage*100) / 100))
+	message := fmt.Sprintf("your %s is rank %v (%v%%)", pokemon, rank, (math.Trunc(spread.Percentage*100) / 100))
 
 	if verbose {
 		message = fmt.Sprintf("%s\n\nCP: `%v`\nLevel: `%v`\nAttack: `%v`\nDefense: `%v`\nHP: `%v`\nProduct: `%v`", message, spread.CP, spread.Level, spread.Stats.Attack, spread.Stats.Defense, spread.Stats.HP, spread.Product)
@@ -127,7 +141,7 @@ func getRank(pieces []string, m *discordgo.MessageCreate, verbose bool, betterth
 		hatched := float64(math.Round(float64(*spread.Ranks.Hatched-1)/216*100*100)) / 100
 		lucky := float64(math.Round(float64(*spread.Ranks.Lucky-1)/64*100*100)) / 100
 
-		message = fmt.Sprintf("%s\n\nYour chances of getting a better %s:\n\n`%v%%`: Wild catch", message, query.Pokemon, wild)
+		message = fmt.Sprintf("%s\n\nYour chances of getting a better %s:\n\n`%v%%`: Wild catch", message, pokemon, wild)
 		message = fmt.Sprintf("%s\n`%v%%`: Trade with Good Friend", message, good)
 		message = fmt.Sprintf("%s\n`%v%%`: Trade with Great Friend", message, great)
 		message = fmt.Sprintf("%s\n`%v%%`: Trade with Ultra Friend", message, ultra)
@@ -139,58 +153,3 @@ func getRank(pieces []string, m *discordgo.MessageCreate, verbose bool, betterth
 
 	return message
 }
-
-func parseQuery(p []string) (Query, error) {
-	// turn "rank azumarill 4/1/3" into "rank azumarill 4 1 3"
-	if strings.Count(p[len(p)-1], "/") == 2 {
-		proper := strings.Split(p[len(p)-1], "/")
-		p[len(p)-1] = proper[0]
-		p = append(p, proper[1], proper[2])
-	}
-	floor, ok := FloorMap[p[len(p)-1]]
-	if ok {
-		p = p[:len(p)-1]
-	}
-
-	if len(p) < 4 {
-		return Query{}, fmt.Errorf("not enough IVs")
-	}
-	q := Query{
-		Atk:   p[len(p)-3],
-		Def:   p[len(p)-2],
-		HP:    p[len(p)-1],
-		Floor: floor,
-	}
-
-	if p[0] == "great" || p[0] == "ultra" || p[0] == "master" {
-		if len(p) < 5 {
-			return q, fmt.Errorf("not enough IVs")
-		}
-		q.League = p[0]
-		q.Pokemon = strings.Join(p[1:len(p)-3], " ")
-	} else {
-		q.League = "great"
-		q.Pokemon = strings.Join(p[:len(p)-3], " ")
-	}
-
-	return q, nil
-}
-
-func parseIVs(atk, def, hp string) (iAtk, iDef, iHP int, err error) {
-	errMsg := "`%s` doesn't appear to be a number between 0-15"
-	iAtk, err = strconv.Atoi(atk)
-	if err != nil || iAtk < 0 || iAtk > 15 {
-		err = fmt.Errorf(errMsg, atk)
-		return
-	}
-	iDef, err = strconv.Atoi(def)
-	if err != nil || iDef < 0 || iDef > 15 {
-		err = fmt.Errorf(errMsg, def)
-		return
-	}
-	iHP, err = strconv.Atoi(hp)
-	if err != nil || iHP < 0 || iHP > 15 {
-		err = fmt.Errorf(errMsg, hp)
-	}
-	return
-}
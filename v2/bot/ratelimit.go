@@ -0,0 +1,133 @@
+package bot
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Sigafoos/wobbotfet/config"
+	"github.com/Sigafoos/wobbotfet/ratelimit"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Scopes of a rate limit, for telling the user which one they hit.
+const (
+	limitUser    = "user"
+	limitGuild   = "guild"
+	limitCommand = "command"
+)
+
+// limits are the rate limits on commands. They're replaced when the config is reloaded.
+var limits = struct {
+	sync.Mutex
+	user     *ratelimit.Limiter
+	guild    *ratelimit.Limiter
+	commands map[string]*ratelimit.Limiter
+	// warned is when the cooldown ends for everyone who's been told they're rate limited, so they're only told once
+	// rather than every time they try.
+	warned map[string]time.Time
+}{
+	user:     ratelimit.New(ratelimit.Limit{}),
+	guild:    ratelimit.New(ratelimit.Limit{}),
+	commands: make(map[string]*ratelimit.Limiter),
+	warned:   make(map[string]time.Time),
+}
+
+// setupRateLimits replaces the rate limits. The config has already been validated.
+func setupRateLimits(c config.RateLimit) {
+	user, _ := ratelimit.Parse(c.User)
+	guild, _ := ratelimit.Parse(c.Guild)
+	commands := make(map[string]*ratelimit.Limiter)
+	for command, limit := range c.Commands {
+		l, _ := ratelimit.Parse(limit)
+		commands[command] = ratelimit.New(l)
+	}
+
+	limits.Lock()
+	defer limits.Unlock()
+	limits.user = ratelimit.New(user)
+	limits.guild = ratelimit.New(guild)
+	limits.commands = commands
+	limits.warned = make(map[string]time.Time)
+}
+
+// rateLimit checks whether m's author can use command now and, if they can, uses up a go. If they can't, it returns
+// what they should be told, which is empty if they've been told already.
+func rateLimit(command string, m *discordgo.MessageCreate, s *discordgo.Session) (string, bool) {
+	wait, scope, key := checkLimits(command, m)
+	// working out who's an admin can mean asking Discord, so it's only done for the people who'd be limited
+	if wait == 0 || bypassesLimits(m, s) {
+		return "", false
+	}
+
+	limits.Lock()
+	defer limits.Unlock()
+	now := time.Now()
+	if until, ok := limits.warned[key]; ok && now.Before(until) {
+		return "", true
+	}
+	limits.warned[key] = now.Add(wait)
+	return cooldownMessage(scope, command, wait), true
+}
+
+// checkLimits returns how long until m's author can use command, and the limit that's making them wait. If they don't
+// have to, it uses up a go of every limit.
+func checkLimits(command string, m *discordgo.MessageCreate) (wait time.Duration, scope, key string) {
+	limits.Lock()
+	defer limits.Unlock()
+
+	type check struct {
+		scope   string
+		limiter *ratelimit.Limiter
+		key     string
+	}
+	checks := []check{{limitUser, limits.user, m.Author.ID}}
+	if m.GuildID != "" {
+		checks = append(checks, check{limitGuild, limits.guild, m.GuildID})
+	}
+	if l, ok := limits.commands[command]; ok {
+		// the command's in the key so being told about one command's limit doesn't hide another's
+		checks = append(checks, check{limitCommand, l, command + ":" + m.Author.ID})
+	}
+
+	// only use up a go if every limit allows it, so being told to wait doesn't make the wait longer
+	for _, c := range checks {
+		if w := c.limiter.Wait(c.key); w > wait {
+			wait, scope, key = w, c.scope, c.scope+":"+c.key
+		}
+	}
+	if wait > 0 {
+		return wait, scope, key
+	}
+
+	for _, c := range checks {
+		c.limiter.Take(c.key)
+	}
+	now := time.Now()
+	for k, until := range limits.warned {
+		if now.After(until) {
+			delete(limits.warned, k)
+		}
+	}
+	return 0, "", ""
+}
+
+// bypassesLimits reports whether the author of a message isn't rate limited: the owner, and the server's admins.
+func bypassesLimits(m *discordgo.MessageCreate, s *discordgo.Session) bool {
+	if current != nil && current.IsOwner(m.Author.ID) {
+		return true
+	}
+	return m.GuildID != "" && isAdmin(m, s)
+}
+
+func cooldownMessage(scope, command string, wait time.Duration) string {
+	// round up, so they don't try again a moment too soon
+	seconds := int((wait + time.Second - 1) / time.Second)
+	switch scope {
+	case limitGuild:
+		return fmt.Sprintf("I'm getting a lot of requests from this server. try again in %ds", seconds)
+	case limitCommand:
+		return fmt.Sprintf("slow down! you can use `%s` again in %ds", command, seconds)
+	}
+	return fmt.Sprintf("slow down! try again in %ds", seconds)
+}
@@ -0,0 +1,50 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/Sigafoos/wobbotfet/config"
+	"github.com/bwmarrin/discordgo"
+)
+
+func testMessage(user, guild string) *discordgo.MessageCreate {
+	return &discordgo.MessageCreate{Message: &discordgo.Message{GuildID: guild, Author: &discordgo.User{ID: user}}}
+}
+
+func TestCheckLimits(t *testing.T) {
+	setupRateLimits(config.RateLimit{
+		User:     "2/1h",
+		Guild:    "1/1h",
+		Commands: map[string]string{"rank": "1/1h"},
+	})
+	defer setupRateLimits(config.RateLimit{})
+
+	if wait, _, _ := checkLimits("help", testMessage("a", "g")); wait != 0 {
+		t.Fatalf("first go waiting %s", wait)
+	}
+
+	// the guild's used up, which shouldn't use up b's go
+	wait, scope, key := checkLimits("help", testMessage("b", "g"))
+	if wait == 0 || scope != limitGuild || key != "guild:g" {
+		t.Errorf("got %s %q %q, want the guild limit", wait, scope, key)
+	}
+	for _, guild := range []string{"h", "i"} {
+		if wait, scope, _ := checkLimits("help", testMessage("b", guild)); wait != 0 {
+			t.Errorf("b waiting %s for the %s limit in %s, after being told to wait elsewhere", wait, scope, guild)
+		}
+	}
+	if wait, scope, _ := checkLimits("help", testMessage("b", "j")); wait == 0 || scope != limitUser {
+		t.Errorf("b got %s %q after 2 goes, want the user limit", wait, scope)
+	}
+
+	// in a PM there's no guild limit, but the command has one
+	if wait, _, _ := checkLimits("rank", testMessage("c", "")); wait != 0 {
+		t.Errorf("c waiting %s for their first rank", wait)
+	}
+	if wait, scope, key := checkLimits("rank", testMessage("c", "")); wait == 0 || scope != limitCommand || key != "command:rank:c" {
+		t.Errorf("got %s %q %q, want the rank limit", wait, scope, key)
+	}
+	if wait, _, _ := checkLimits("help", testMessage("c", "")); wait != 0 {
+		t.Errorf("c waiting %s for another command", wait)
+	}
+}
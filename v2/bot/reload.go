@@ -0,0 +1,115 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/Sigafoos/wobbotfet/config"
+	"github.com/Sigafoos/wobbotfet/guildconfig"
+	"github.com/bwmarrin/discordgo"
+)
+
+// serviceCommands are the commands each service registers, so they can be unregistered if it's removed.
+var serviceCommands = []struct {
+	service  func(c *config.Config) config.Service
+	commands []string
+}{
+	{func(c *config.Config) config.Service { return c.Rank }, []string{"rank", "vrank", "betterthan"}},
+	{func(c *config.Config) config.Service { return c.Want }, []string{"want", "unwant", "wants", "search"}},
+	{func(c *config.Config) config.Service { return c.PVP }, []string{"pvp"}},
+}
+
+// setupServices registers the commands for every configured service, replacing any already registered, and then
+// unregisters the commands for services that aren't configured. A command that's staying is never missing.
+func setupServices(c *config.Config) {
+	setupRank(c.Rank)
+	setupWant(c.Want)
+	setupPVP(c.PVP)
+
+	for _, s := range serviceCommands {
+		if s.service(c).URL != "" {
+			continue
+		}
+		for _, key := range s.commands {
+			unregisterCommand(key)
+		}
+	}
+}
+
+// Reload loads the config again and applies it without reconnecting to Discord: commands are registered or
+// unregistered as services are added or removed, and the help text follows. It returns what's disabled by the new
+// config. The token, API, health check and error digest settings can't be changed without a restart.
+func (b *Bot) Reload() ([]string, error) {
+	b.reloadMu.Lock()
+	defer b.reloadMu.Unlock()
+
+	old := b.settings()
+	c, err := old.config.Reload()
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Token != old.config.Token {
+		log.Println("the token has changed; restart to use it")
+	}
+	if c.API != old.config.API {
+		log.Println("the API settings have changed; restart to use them")
+	}
+	if c.Health != old.config.Health {
+		log.Println("the health check settings have changed; restart to use them")
+	}
+	if c.Errors != old.config.Errors {
+		log.Println("the error digest settings have changed; restart to use them")
+	}
+
+	// everything that can fail happens before anything's changed
+	var store *guildconfig.Store
+	if c.GuildConfig != old.config.GuildConfig {
+		store, err = guildconfig.Open(c.GuildConfig)
+		if err != nil {
+			return nil, fmt.Errorf("cannot open guild config: %w", err)
+		}
+	}
+
+	owner, pm := old.owner, old.pm
+	if c.Owner != old.config.Owner {
+		owner, pm = nil, nil
+		if c.Owner != "" {
+			owner = &discordgo.User{ID: c.Owner}
+			pm, err = b.session.UserChannelCreate(c.Owner)
+			if err != nil {
+				log.Printf("error opening PM with owner: %s", err.Error())
+			}
+		}
+	}
+
+	if store != nil {
+		setGuildStore(store)
+	}
+	setupServices(c)
+	setupRateLimits(c.RateLimit)
+	setupFeatures(c.DisabledCommands)
+	b.update(func(s *settings) {
+		s.config = c
+		s.owner = owner
+		s.pm = pm
+		s.version = c.Version
+	})
+
+	if c.Version != old.version {
+		b.updateStatus()
+	}
+	if u := b.session.State.User; u != nil {
+		b.registerSlashCommands(u.ID)
+	}
+
+	report := c.Report()
+	message := "reloaded config. known commands: " + strings.Join(commandNames(), ", ")
+	if len(report) > 0 {
+		message += "\n\n" + strings.Join(report, "\n")
+	}
+	log.Println(message)
+	b.PM(message)
+	return report, nil
+}
@@ -0,0 +1,108 @@
+package bot
+
+import (
+	"errors"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// ErrEmptyMessage is returned when sending a message with no content or embed.
+var ErrEmptyMessage = errors.New("a message needs content or an embed")
+
+// A Message is something to post: text, an embed or both.
+type Message struct {
+	Content string                  `json:"content"`
+	Embed   *discordgo.MessageEmbed `json:"embed,omitempty"`
+}
+
+// Validate returns an error if Discord won't accept the message.
+func (m *Message) Validate() error {
+	if m.Content == "" && m.Embed == nil {
+		return ErrEmptyMessage
+	}
+	if len(m.Content) > 2000 {
+		return errors.New("the content can be at most 2000 characters")
+	}
+	return nil
+}
+
+// Send posts a message to a channel.
+func (b *Bot) Send(channel string, m *Message) (*discordgo.Message, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return b.session.ChannelMessageSendComplex(channel, &discordgo.MessageSend{
+		Content: m.Content,
+		Embed:   m.Embed,
+	})
+}
+
+// DM sends a message to a user in a PM.
+func (b *Bot) DM(user string, m *Message) (*discordgo.Message, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	pm, err := b.session.UserChannelCreate(user)
+	if err != nil {
+		return nil, err
+	}
+	return b.Send(pm.ID, m)
+}
+
+// ChannelGuild returns the guild a channel is in. It's empty for a PM.
+func (b *Bot) ChannelGuild(channel string) (string, error) {
+	c, err := b.session.State.Channel(channel)
+	if err != nil {
+		c, err = b.session.Channel(channel)
+		if err != nil {
+			return "", err
+		}
+	}
+	return c.GuildID, nil
+}
+
+// A BroadcastResult is what happened to an announcement in a guild.
+type BroadcastResult struct {
+	Guild   string `json:"guild"`
+	Channel string `json:"channel,omitempty"`
+	Sent    bool   `json:"sent"`
+	// Skipped is why it wasn't sent, if the guild doesn't want it.
+	Skipped string `json:"skipped,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Broadcast posts an announcement to the announce channel of every guild that has one and hasn't opted out. With
+// dryRun, it only says where it would be posted.
+func (b *Bot) Broadcast(m *Message, dryRun bool) ([]BroadcastResult, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	servers, err := b.Servers()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BroadcastResult, len(servers))
+	for i, server := range servers {
+		c := guildConfig(server.ID)
+		result := BroadcastResult{
+			Guild:   server.ID,
+			Channel: c.AnnounceChannel,
+		}
+		switch {
+		case !c.Announcements:
+			result.Skipped = "opted out"
+		case c.AnnounceChannel == "":
+			result.Skipped = "no announce channel"
+		case dryRun:
+		default:
+			if _, err := b.Send(c.AnnounceChannel, m); err != nil {
+				result.Error = err.Error()
+			} else {
+				result.Sent = true
+			}
+		}
+		results[i] = result
+	}
+	return results, nil
+}
@@ -0,0 +1,130 @@
+package bot
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// userGuildsLimit is the most guilds Discord returns at once.
+const userGuildsLimit = 100
+
+// servers caches the servers the bot is in. It's filled from the API the first time it's needed, and kept up to date
+// by the guild events from the gateway.
+var servers = struct {
+	sync.RWMutex
+	loaded bool
+	byID   map[string]*discordgo.UserGuild
+}{byID: make(map[string]*discordgo.UserGuild)}
+
+// trackServers keeps the server cache up to date.
+func (b *Bot) trackServers() {
+	b.session.AddHandler(func(s *discordgo.Session, e *discordgo.GuildCreate) {
+		b.cacheServer(e.Guild)
+	})
+	b.session.AddHandler(func(s *discordgo.Session, e *discordgo.GuildUpdate) {
+		b.cacheServer(e.Guild)
+	})
+	b.session.AddHandler(func(s *discordgo.Session, e *discordgo.GuildDelete) {
+		// unavailable means there's an outage, not that we've left
+		if e.Unavailable {
+			return
+		}
+		servers.Lock()
+		delete(servers.byID, e.ID)
+		servers.Unlock()
+	})
+}
+
+func (b *Bot) cacheServer(g *discordgo.Guild) {
+	ug := &discordgo.UserGuild{
+		ID:    g.ID,
+		Name:  g.Name,
+		Icon:  g.Icon,
+		Owner: g.OwnerID == b.session.State.User.ID,
+	}
+	// the permissions are worked out from the member list, which a guild update doesn't have, so use the state's
+	if guild, err := b.session.State.Guild(g.ID); err == nil {
+		if member, err := b.session.State.Member(g.ID, b.session.State.User.ID); err == nil {
+			ug.Permissions = guildPermissions(guild, member)
+		}
+	}
+
+	servers.Lock()
+	servers.byID[g.ID] = ug
+	servers.Unlock()
+}
+
+// Servers returns every server the bot is in, sorted by name.
+func (b *Bot) Servers() ([]*discordgo.UserGuild, error) {
+	servers.RLock()
+	loaded := servers.loaded
+	servers.RUnlock()
+	if !loaded {
+		if err := b.loadServers(); err != nil {
+			return nil, err
+		}
+	}
+
+	servers.RLock()
+	list := make([]*discordgo.UserGuild, 0, len(servers.byID))
+	for _, s := range servers.byID {
+		list = append(list, s)
+	}
+	servers.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		return strings.ToLower(list[i].Name) < strings.ToLower(list[j].Name)
+	})
+	return list, nil
+}
+
+// loadServers fills the cache from the API, a page at a time.
+func (b *Bot) loadServers() error {
+	var all []*discordgo.UserGuild
+	after := ""
+	for {
+		page, err := b.session.UserGuilds(userGuildsLimit, "", after)
+		if err != nil {
+			return err
+		}
+		all = append(all, page...)
+		if len(page) < userGuildsLimit {
+			break
+		}
+		after = page[len(page)-1].ID
+	}
+
+	servers.Lock()
+	defer servers.Unlock()
+	for _, s := range all {
+		servers.byID[s.ID] = s
+	}
+	servers.loaded = true
+	return nil
+}
+
+// Server returns the details of a server the bot is in, or discordgo.ErrStateNotFound if it isn't.
+func (b *Bot) Server(server string) (*discordgo.Guild, error) {
+	return b.session.State.Guild(server)
+}
+
+// guildPermissions returns a member's permissions in a guild: those of all their roles, plus @everyone.
+func guildPermissions(guild *discordgo.Guild, member *discordgo.Member) int {
+	var perms int
+	for _, role := range guild.Roles {
+		// the @everyone role has the guild's ID
+		if role.ID == guild.ID {
+			perms |= role.Permissions
+			continue
+		}
+		for _, id := range member.Roles {
+			if role.ID == id {
+				perms |= role.Permissions
+			}
+		}
+	}
+	return perms
+}
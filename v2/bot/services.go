@@ -0,0 +1,53 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/Sigafoos/wobbotfet/backend"
+	"github.com/Sigafoos/wobbotfet/config"
+	"github.com/bwmarrin/discordgo"
+)
+
+// current is the running Bot, so service state changes can be reported to its owner.
+var current *Bot
+
+// servicesMu guards the service clients (and the PVP state), which are replaced when the config is reloaded. Use their
+// functions, ie ranks().
+var servicesMu sync.RWMutex
+
+// requestContext returns the context to make service requests with. The message ID is used as the request ID so a
+// command can be traced through the services' logs.
+func requestContext(m *discordgo.MessageCreate) context.Context {
+	return backend.WithRequestID(context.Background(), m.ID)
+}
+
+// serviceOptions returns the options for a service client.
+func serviceOptions(c config.Service) []backend.Option {
+	opts := []backend.Option{
+		backend.OnStateChange(serviceStateChanged),
+		backend.Observe(recordBackendCall),
+		backend.Observe(observeBackend),
+		backend.Observe(publishBackendError),
+		backend.BasicAuth(c.BasicUser, c.BasicPass),
+	}
+	if c.Timeout > 0 {
+		opts = append(opts, backend.Timeout(c.Timeout))
+	}
+	return opts
+}
+
+// serviceStateChanged lets the owner know when a service goes down or comes back up.
+func serviceStateChanged(service string, from, to backend.State) {
+	log.Printf("%s service: %s -> %s", service, from, to)
+
+	// going from down to recovering (or failing while recovering) isn't news
+	if to == backend.StateHalfOpen || (to == backend.StateOpen && from == backend.StateHalfOpen) {
+		return
+	}
+	if current != nil {
+		current.PM(fmt.Sprintf("the %s service is %s", service, to))
+	}
+}
@@ -0,0 +1,325 @@
+package bot
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// slashAPI is where slash commands are registered and answered. discordgo's endpoints are for a version of the API
+// that doesn't have them.
+const slashAPI = "https://discord.com/api/v8/"
+
+// Types of slash command option.
+const (
+	optionSubcommand = 1
+	optionString     = 3
+	optionInteger    = 4
+	optionBoolean    = 5
+	optionChannel    = 7
+)
+
+const (
+	// interactionCommand is an interaction that's a slash command being used.
+	interactionCommand = 2
+	// interactionComponent is a button being clicked.
+	interactionComponent = 3
+	// responseDeferred tells Discord the response is coming, so it shows wobbotfet thinking.
+	responseDeferred = 5
+	// responseDeferredUpdate tells Discord a button click has been seen, and the message will be updated if need be.
+	responseDeferredUpdate = 6
+	// responseUpdate updates the message with the button that was clicked.
+	responseUpdate = 7
+)
+
+// Discord's limits on slash commands.
+const (
+	maxSlashDescription = 100
+	maxSlashChoices     = 25
+)
+
+// A slashCommand is a command as it's registered with Discord.
+type slashCommand struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Options     []slashOption `json:"options,omitempty"`
+}
+
+type slashOption struct {
+	Type        int           `json:"type"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Required    bool          `json:"required,omitempty"`
+	Choices     []slashChoice `json:"choices,omitempty"`
+	MinValue    *int          `json:"min_value,omitempty"`
+	MaxValue    *int          `json:"max_value,omitempty"`
+	Options     []slashOption `json:"options,omitempty"`
+}
+
+type slashChoice struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// slash returns the command as it's registered with Discord.
+func (sp *commandSpec) slash() slashCommand {
+	return slashCommand{
+		Name:        sp.name,
+		Description: slashDescription(sp.description, sp.name),
+		Options:     sp.slashOptions(),
+	}
+}
+
+func (sp *commandSpec) slashOptions() []slashOption {
+	var options []slashOption
+	if len(sp.subcommands) > 0 {
+		for _, sub := range sp.subcommands {
+			options = append(options, slashOption{
+				Type:        optionSubcommand,
+				Name:        sub.name,
+				Description: slashDescription(sub.description, sub.name),
+				Options:     sub.slashOptions(),
+			})
+		}
+		return options
+	}
+
+	// Discord wants the required options first. They're put back in order when they're turned into words.
+	var optional []slashOption
+	for _, a := range sp.args {
+		o := a.slashOption()
+		if o.Required {
+			options = append(options, o)
+		} else {
+			optional = append(optional, o)
+		}
+	}
+	return append(options, optional...)
+}
+
+func (a arg) slashOption() slashOption {
+	o := slashOption{
+		Type:        optionString,
+		Name:        a.name,
+		Description: slashDescription(a.description, a.name),
+		// there's no way to say `none` to a channel, so leaving it out is how it's cleared
+		Required: !a.optional && !a.clear,
+	}
+	switch a.kind {
+	case kindInt:
+		o.Type = optionInteger
+		if a.max != 0 {
+			o.MinValue, o.MaxValue = &a.min, &a.max
+		}
+	case kindBool:
+		o.Type = optionBoolean
+	case kindChannel:
+		o.Type = optionChannel
+	}
+	if len(a.choices) > 0 && len(a.choices) <= maxSlashChoices {
+		for _, choice := range a.choices {
+			o.Choices = append(o.Choices, slashChoice{Name: choice, Value: choice})
+		}
+	}
+	return o
+}
+
+// slashDescription fits a description into what Discord allows, which isn't markdown and can't be empty.
+func slashDescription(description, name string) string {
+	description = strings.Replace(description, "`", "", -1)
+	if description == "" {
+		return name
+	}
+	if len(description) > maxSlashDescription {
+		return description[:maxSlashDescription-3] + "..."
+	}
+	return description
+}
+
+// handleSlashCommands registers the slash commands whenever wobbotfet connects, and answers them.
+func (b *Bot) handleSlashCommands() {
+	b.session.AddHandler(func(s *discordgo.Session, r *discordgo.Ready) {
+		b.registerSlashCommands(r.User.ID)
+	})
+	b.session.AddHandler(b.readInteraction)
+}
+
+// registerSlashCommands replaces the application's slash commands with the commands that are registered now. If slash
+// commands are turned off it removes them.
+func (b *Bot) registerSlashCommands(application string) {
+	slash := []slashCommand{}
+	if b.settings().config.SlashCommands {
+		for _, name := range commandNames() {
+			sp, ok := getCommand(name)
+			if !ok || sp.noSlash || !featureEnabled(name) {
+				continue
+			}
+			slash = append(slash, sp.slash())
+		}
+	}
+
+	url := slashAPI + "applications/" + application + "/commands"
+	if _, err := b.session.RequestWithBucketID("PUT", url, slash, url); err != nil {
+		logError(nil, "error registering slash commands: %s", err)
+	}
+}
+
+// An interaction is a slash command being used, or a button being clicked.
+type interaction struct {
+	ID            string `json:"id"`
+	ApplicationID string `json:"application_id"`
+	Type          int    `json:"type"`
+	Data          struct {
+		Name    string              `json:"name"`
+		Options []interactionOption `json:"options"`
+		// CustomID is the button's.
+		CustomID string `json:"custom_id"`
+	} `json:"data"`
+	// Message is the message the button's on.
+	Message *struct {
+		ID string `json:"id"`
+	} `json:"message"`
+	GuildID   string `json:"guild_id"`
+	ChannelID string `json:"channel_id"`
+	// Member is set in a server, and User in a PM.
+	Member *struct {
+		User *discordgo.User `json:"user"`
+	} `json:"member"`
+	User  *discordgo.User `json:"user"`
+	Token string          `json:"token"`
+}
+
+type interactionOption struct {
+	Name    string              `json:"name"`
+	Type    int                 `json:"type"`
+	Value   json.RawMessage     `json:"value"`
+	Options []interactionOption `json:"options"`
+}
+
+// readInteraction runs a slash command the way the same command would be run from a message, or answers a question with
+// a button.
+func (b *Bot) readInteraction(s *discordgo.Session, e *discordgo.Event) {
+	defer recoverEvent(nil)
+
+	if e.Type != "INTERACTION_CREATE" {
+		return
+	}
+	var i interaction
+	if err := json.Unmarshal(e.RawData, &i); err != nil {
+		logError(nil, "error reading interaction: %s", err)
+		return
+	}
+	if i.Type == interactionComponent {
+		readButton(s, &i)
+		return
+	}
+	if i.Type != interactionCommand {
+		return
+	}
+
+	pieces := []string{i.Data.Name}
+	if sp, ok := getCommand(i.Data.Name); ok {
+		pieces = append(pieces, optionWords(sp, i.Data.Options)...)
+	}
+	m := i.message(pieces)
+	if m.Author == nil {
+		return
+	}
+
+	// Discord wants an answer within 3 seconds, so say wobbotfet's thinking and follow up
+	callback := slashAPI + "interactions/" + i.ID + "/" + i.Token + "/callback"
+	if _, err := s.RequestWithBucketID("POST", callback, map[string]int{"type": responseDeferred}, callback); err != nil {
+		logError(m, "error responding to interaction: %s", err)
+		return
+	}
+
+	c, f, args := routeCommand(pieces, m)
+	c.interaction = &i
+	handle(c, f)(args, m, s)
+}
+
+// optionWords turns a slash command's options back into the words that would have been typed for it.
+func optionWords(sp *commandSpec, options []interactionOption) []string {
+	for _, o := range options {
+		if o.Type == optionSubcommand {
+			sub := sp.subcommand(o.Name)
+			if sub == nil {
+				return []string{o.Name}
+			}
+			return append([]string{o.Name}, optionWords(sub, o.Options)...)
+		}
+	}
+
+	given := make(map[string]interactionOption)
+	for _, o := range options {
+		given[o.Name] = o
+	}
+	var words []string
+	for _, a := range sp.args {
+		o, ok := given[a.name]
+		if !ok {
+			if a.clear {
+				words = append(words, "none")
+			}
+			continue
+		}
+		words = append(words, o.words()...)
+	}
+	return words
+}
+
+func (o interactionOption) words() []string {
+	switch o.Type {
+	case optionString:
+		var s string
+		json.Unmarshal(o.Value, &s)
+		return strings.Fields(s)
+	case optionInteger:
+		var n json.Number
+		json.Unmarshal(o.Value, &n)
+		return []string{n.String()}
+	case optionBoolean:
+		var b bool
+		json.Unmarshal(o.Value, &b)
+		if b {
+			return []string{"on"}
+		}
+		return []string{"off"}
+	case optionChannel:
+		var id string
+		json.Unmarshal(o.Value, &id)
+		return []string{"<#" + id + ">"}
+	}
+	return nil
+}
+
+// message returns the interaction as a message with the words that would have been typed, so commands (and
+// everything around them) don't need to know the difference.
+func (i *interaction) message(pieces []string) *discordgo.MessageCreate {
+	author := i.User
+	if i.Member != nil && i.Member.User != nil {
+		author = i.Member.User
+	}
+	return &discordgo.MessageCreate{Message: &discordgo.Message{
+		ID:        i.ID,
+		ChannelID: i.ChannelID,
+		GuildID:   i.GuildID,
+		Author:    author,
+		Content:   "/" + strings.Join(pieces, " "),
+	}}
+}
+
+// followUp sends a message in response to the interaction.
+func (i *interaction) followUp(s *discordgo.Session, message string) error {
+	url := slashAPI + "webhooks/" + i.ApplicationID + "/" + i.Token
+	_, err := s.RequestWithBucketID("POST", url, map[string]string{"content": message}, url)
+	return err
+}
+
+// deleteResponse deletes the response saying wobbotfet's thinking, for commands that respond some other way.
+func (i *interaction) deleteResponse(s *discordgo.Session) error {
+	url := slashAPI + "webhooks/" + i.ApplicationID + "/" + i.Token + "/messages/@original"
+	_, err := s.RequestWithBucketID("DELETE", url, nil, url)
+	return err
+}
@@ -0,0 +1,570 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Sigafoos/wobbotfet/eventlog"
+	"github.com/bwmarrin/discordgo"
+)
+
+// A commandSpec describes a command: what it's called, the arguments it takes and what it does. It's what parses the
+// command's arguments, and it's used for the help text and to register the command as a slash command.
+type commandSpec struct {
+	name    string
+	aliases []string
+	// note is who or where it's for, ie `server admins only`, for the help text.
+	note string
+	// description is what it does, in a few words that follow "to", ie `list your wants`. Discord cuts slash command
+	// descriptions off at 100 characters.
+	description string
+	// examples are how it's used, ie `rank azumarill 4 1 3`, for the help text. The first is the one in the list of
+	// commands.
+	examples []string
+	args     []arg
+	// subcommands are picked by the first word. A command with subcommands can't have arguments of its own.
+	subcommands []*commandSpec
+	// run is what it does. A command with subcommands only needs one if it does something without a subcommand.
+	run handler
+	// requires are what has to be true for it to be used. See requirement.
+	requires []requirement
+	// rewrite fixes up the words before they're parsed, for things people type that don't fit the arguments.
+	rewrite func([]string) []string
+	// noSlash keeps it from being registered as a slash command.
+	noSlash bool
+
+	parent *commandSpec
+}
+
+// A handler runs a command with its parsed arguments, returning the response.
+type handler func(a args, m *discordgo.MessageCreate, s *discordgo.Session) string
+
+// An argKind is what sort of value an argument takes.
+type argKind int
+
+const (
+	// kindString is a word, or words if it's variadic.
+	kindString argKind = iota
+	// kindInt is a whole number.
+	kindInt
+	// kindBool is `on` or `off` (or yes/no, true/false).
+	kindBool
+	// kindChannel is a channel, ie `#pvp`. Its value is the channel's ID.
+	kindChannel
+)
+
+// An arg is one of a command's arguments.
+type arg struct {
+	name        string
+	kind        argKind
+	description string
+	// optional arguments can be left out. If the words could be read more than one way, an optional argument gets
+	// a word if it can.
+	optional bool
+	// variadic arguments take one or more words (or none, if they're optional). Only kindString can be variadic.
+	variadic bool
+	// choices are the only words it accepts.
+	choices []string
+	// min and max are the range of a kindInt. They're only checked if max is set.
+	min, max int
+	// clear means it accepts `none`, for an empty value.
+	clear bool
+	// raw keeps the case it was typed in.
+	raw bool
+}
+
+// args are a command's arguments, by name. Arguments that were left out aren't set.
+type args map[string]interface{}
+
+// Has reports whether an argument was given.
+func (a args) Has(name string) bool {
+	_, ok := a[name]
+	return ok
+}
+
+// String returns a kindString or kindChannel argument. A variadic argument's words are joined with spaces.
+func (a args) String(name string) string {
+	switch v := a[name].(type) {
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, " ")
+	}
+	return ""
+}
+
+// Strings returns a variadic argument's words.
+func (a args) Strings(name string) []string {
+	switch v := a[name].(type) {
+	case []string:
+		return v
+	case string:
+		return []string{v}
+	}
+	return nil
+}
+
+func (a args) Int(name string) int {
+	i, _ := a[name].(int)
+	return i
+}
+
+func (a args) Bool(name string) bool {
+	b, _ := a[name].(bool)
+	return b
+}
+
+var (
+	boolOn  = map[string]bool{"on": true, "yes": true, "true": true}
+	boolOff = map[string]bool{"off": true, "no": true, "false": true}
+)
+
+// normalize returns a word the way the argument compares it.
+func (a arg) normalize(word string) string {
+	if a.raw {
+		return word
+	}
+	return strings.ToLower(word)
+}
+
+// accepts reports whether a word could be the argument (or one of its words), without checking ranges.
+func (a arg) accepts(word string) bool {
+	word = a.normalize(word)
+	if a.clear && word == "none" {
+		return true
+	}
+	if len(a.choices) > 0 {
+		for _, choice := range a.choices {
+			if word == choice {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch a.kind {
+	case kindInt:
+		_, err := strconv.Atoi(word)
+		return err == nil
+	case kindBool:
+		return boolOn[word] || boolOff[word]
+	case kindChannel:
+		return channelre.MatchString(word)
+	}
+	return true
+}
+
+// value returns the argument's value from its words, or what's wrong with them.
+func (a arg) value(words []string) (interface{}, error) {
+	for i, word := range words {
+		words[i] = a.normalize(word)
+	}
+	if a.variadic {
+		return words, nil
+	}
+
+	word := words[0]
+	if a.clear && word == "none" {
+		if a.kind == kindBool || a.kind == kindInt {
+			return nil, fmt.Errorf("%s can't be `none`", a.name)
+		}
+		return "", nil
+	}
+	if len(a.choices) > 0 && !a.accepts(word) {
+		return nil, fmt.Errorf("%s should be %s, not `%s`", a.name, orList(a.choices), word)
+	}
+
+	switch a.kind {
+	case kindInt:
+		i, err := strconv.Atoi(word)
+		if err != nil {
+			return nil, fmt.Errorf("%s should be a number, not `%s`", a.name, word)
+		}
+		if a.max != 0 && (i < a.min || i > a.max) {
+			return nil, fmt.Errorf("%s should be between %d and %d, not `%s`", a.name, a.min, a.max, word)
+		}
+		return i, nil
+	case kindBool:
+		if boolOn[word] {
+			return true, nil
+		}
+		if boolOff[word] {
+			return false, nil
+		}
+		return nil, fmt.Errorf("%s should be `on` or `off`, not `%s`", a.name, word)
+	case kindChannel:
+		matches := channelre.FindStringSubmatch(word)
+		if matches == nil {
+			if a.clear {
+				return nil, fmt.Errorf("%s should be a channel (ie `#general`) or `none`, not `%s`", a.name, word)
+			}
+			return nil, fmt.Errorf("%s should be a channel (ie `#general`), not `%s`", a.name, word)
+		}
+		return matches[1], nil
+	}
+	return word, nil
+}
+
+// usage is how the argument's shown in a usage string: `<name>`, `[name]` if it's optional, with `...` if it's
+// variadic.
+func (a arg) usage() string {
+	u := a.name
+	if a.variadic {
+		u += "..."
+	}
+	if a.optional {
+		return "[" + u + "]"
+	}
+	return "<" + u + ">"
+}
+
+// detail is the argument's line in a command's detailed help: what it is and what it takes.
+func (a arg) detail() string {
+	var takes []string
+	switch {
+	case len(a.choices) > 0:
+		takes = append(takes, "one of "+orList(a.choices))
+	case a.kind == kindInt && a.max != 0:
+		takes = append(takes, fmt.Sprintf("%d to %d", a.min, a.max))
+	case a.kind == kindInt:
+		takes = append(takes, "a number")
+	case a.kind == kindBool:
+		takes = append(takes, "`on` or `off`")
+	case a.kind == kindChannel:
+		takes = append(takes, "a channel, ie `#general`")
+	}
+	if a.clear {
+		takes = append(takes, "or `none`")
+	}
+	if a.variadic {
+		takes = append(takes, "as many as you like, separated by spaces")
+	}
+	if a.optional {
+		takes = append(takes, "optional")
+	}
+
+	d := "`" + a.name + "`"
+	if a.description != "" {
+		d += ": " + a.description
+	}
+	if len(takes) > 0 {
+		d += " (" + strings.Join(takes, ", ") + ")"
+	}
+	return d
+}
+
+// orList returns `a`, `b` or `c`.
+func orList(words []string) string {
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		quoted[i] = "`" + w + "`"
+	}
+	if len(quoted) == 1 {
+		return quoted[0]
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + " or " + quoted[len(quoted)-1]
+}
+
+// eachMatch calls visit with every way the words could go to the arguments, in the order they'd be read, until it
+// returns false.
+func eachMatch(as []arg, words []string, strict bool, visit func([][]string) bool) bool {
+	if len(as) == 0 {
+		if len(words) > 0 {
+			return true
+		}
+		return visit([][]string{})
+	}
+
+	a := as[0]
+	// optional arguments take a word if they can, and variadic ones take as few as they can, which is how people
+	// read `rank deoxys defense 4 1 3`
+	var tries []int
+	if a.variadic {
+		start := 1
+		if a.optional {
+			start = 0
+		}
+		for n := start; n <= len(words); n++ {
+			tries = append(tries, n)
+		}
+	} else {
+		if len(words) > 0 {
+			tries = append(tries, 1)
+		}
+		if a.optional {
+			tries = append(tries, 0)
+		}
+	}
+
+	for _, n := range tries {
+		if !fits(a, words[:n], strict) {
+			continue
+		}
+		first := words[:n]
+		more := eachMatch(as[1:], words[n:], strict, func(rest [][]string) bool {
+			return visit(append([][]string{first}, rest...))
+		})
+		if !more {
+			return false
+		}
+	}
+	return true
+}
+
+func fits(a arg, words []string, strict bool) bool {
+	if !strict && !(a.optional && len(a.choices) > 0) {
+		return true
+	}
+	for _, word := range words {
+		if !a.accepts(word) {
+			return false
+		}
+	}
+	return true
+}
+
+// parse parses the words after the command into its arguments.
+func (sp *commandSpec) parse(words []string) (args, error) {
+	if sp.rewrite != nil {
+		words = sp.rewrite(words)
+	}
+
+	if a, ok := sp.read(words); ok {
+		return a, nil
+	}
+	return nil, sp.usageError(sp.explain(words))
+}
+
+// read returns the arguments from the first way of reading the words where every argument's value works.
+func (sp *commandSpec) read(words []string) (args, bool) {
+	var a args
+	eachMatch(sp.args, words, true, func(matched [][]string) bool {
+		var err error
+		a, err = sp.values(matched)
+		return err != nil
+	})
+	return a, a != nil
+}
+
+// values returns the arguments' values from the words matched to each, or the first thing wrong with them.
+func (sp *commandSpec) values(matched [][]string) (args, error) {
+	a := make(args)
+	for i, ar := range sp.args {
+		if len(matched[i]) == 0 {
+			continue
+		}
+		v, err := ar.value(append([]string{}, matched[i]...))
+		if err != nil {
+			return nil, err
+		}
+		a[ar.name] = v
+	}
+	return a, nil
+}
+
+// usageError is a problem with a command's arguments, followed by how it's used.
+func (sp *commandSpec) usageError(problem string) error {
+	return fmt.Errorf("%s\nusage: `%s` (`%s help` for more)", problem, sp.usage(), sp.path())
+}
+
+// explain says what's wrong with words that can't be parsed into the arguments.
+func (sp *commandSpec) explain(words []string) string {
+	// it's right apart from what's on the end. if that's all of it, the words are more likely wrong than extra.
+	for n := len(words) - 1; n > 0 || n == 0 && len(sp.args) == 0; n-- {
+		if _, ok := sp.read(words[:n]); ok {
+			return fmt.Sprintf("I don't know what `%s` is for", strings.Join(words[n:], " "))
+		}
+	}
+
+	// it's right as far as it goes
+	if missing, ok := sp.missing(words); ok {
+		if missing.description == "" {
+			return fmt.Sprintf("`%s` needs %s", sp.path(), missing.usage())
+		}
+		return fmt.Sprintf("`%s` needs %s: %s", sp.path(), missing.usage(), missing.description)
+	}
+
+	// the right number of words, but some of them don't fit: blame the way of reading them with the fewest
+	var problem string
+	fewest := len(sp.args) + 1
+	eachMatch(sp.args, words, false, func(matched [][]string) bool {
+		var first error
+		wrong := 0
+		for i, a := range sp.args {
+			if len(matched[i]) == 0 {
+				continue
+			}
+			if _, err := a.value(append([]string{}, matched[i]...)); err != nil {
+				if first == nil {
+					first = err
+				}
+				wrong++
+			}
+		}
+		if wrong > 0 && wrong < fewest {
+			problem, fewest = first.Error(), wrong
+		}
+		return true
+	})
+	if problem != "" {
+		return problem
+	}
+	return "that doesn't fit"
+}
+
+// missing returns the first argument that didn't get a word, reading the words left to right.
+func (sp *commandSpec) missing(words []string) (arg, bool) {
+	for _, a := range sp.args {
+		if a.optional {
+			if len(a.choices) > 0 && len(words) > 0 && a.accepts(words[0]) {
+				words = words[1:]
+			}
+			continue
+		}
+		if len(words) == 0 {
+			return a, true
+		}
+		words = words[1:]
+	}
+	return arg{}, false
+}
+
+// path is the command's full name, ie `pvp ultra`.
+func (sp *commandSpec) path() string {
+	if sp.parent == nil {
+		return sp.name
+	}
+	return sp.parent.path() + " " + sp.name
+}
+
+// usage is how the command's used, ie `rank [league] <pokemon...> <atk> <def> <hp> [floor]`.
+func (sp *commandSpec) usage() string {
+	u := sp.path()
+	if len(sp.subcommands) > 0 {
+		names := make([]string, len(sp.subcommands))
+		for i, sub := range sp.subcommands {
+			names[i] = sub.name
+		}
+		u += " <" + strings.Join(names, "|") + ">"
+	}
+	for _, a := range sp.args {
+		u += " " + a.usage()
+	}
+	return u
+}
+
+// help is the command's line in the help text.
+func (sp *commandSpec) help() string {
+	var h string
+	if sp.note != "" {
+		h = "(" + sp.note + ") "
+	}
+	if len(sp.examples) == 0 {
+		return h + sp.description
+	}
+	return h + "`" + sp.examples[0] + "` to " + sp.description
+}
+
+// detail is everything about the command, for `help <command>` and `<command> help`.
+func (sp *commandSpec) detail() string {
+	lines := []string{"`" + sp.usage() + "`: " + sp.description}
+	if sp.note != "" {
+		lines[0] += " (" + sp.note + ")"
+	}
+	if len(sp.aliases) > 0 {
+		lines = append(lines, "also "+orList(sp.aliases))
+	}
+
+	if len(sp.args) > 0 {
+		lines = append(lines, "", "**arguments**")
+		for _, a := range sp.args {
+			lines = append(lines, a.detail())
+		}
+	}
+	if len(sp.subcommands) > 0 {
+		lines = append(lines, "", "**commands**", sp.describeSubcommands(),
+			"`"+sp.path()+" <command> help` for more about one")
+	}
+	if len(sp.examples) > 0 {
+		lines = append(lines, "", "**examples**")
+		for _, e := range sp.examples {
+			lines = append(lines, "`"+e+"`")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// describeSubcommands lists a command's subcommands and what they do.
+func (sp *commandSpec) describeSubcommands() string {
+	lines := make([]string, len(sp.subcommands))
+	for i, sub := range sp.subcommands {
+		lines[i] = "`" + sub.usage() + "`: " + sub.description
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (sp *commandSpec) subcommand(name string) *commandSpec {
+	for _, sub := range sp.subcommands {
+		if sub.name == name {
+			return sub
+		}
+		for _, alias := range sub.aliases {
+			if alias == name {
+				return sub
+			}
+		}
+	}
+	return nil
+}
+
+// command returns the command that runs sp with the words it's given, recording anything that stops it on c.
+func (sp *commandSpec) command(c *call) command {
+	return func(pieces []string, m *discordgo.MessageCreate, s *discordgo.Session) string {
+		return sp.dispatch(c, pieces, m, s)
+	}
+}
+
+func (sp *commandSpec) dispatch(c *call, words []string, m *discordgo.MessageCreate, s *discordgo.Session) string {
+	// `<command> help`, unless help is something the command does itself
+	if len(words) > 0 && strings.ToLower(words[0]) == "help" && sp.subcommand("help") == nil {
+		return sp.detail()
+	}
+
+	// the command's own requirements have been checked already, but a subcommand's haven't
+	if sp.parent != nil {
+		for _, r := range sp.requires {
+			if refusal := r(m, s); refusal != "" {
+				c.event.Outcome = eventlog.OutcomeForbidden
+				return refusal
+			}
+		}
+	}
+
+	if len(sp.subcommands) > 0 && len(words) > 0 {
+		sub := sp.subcommand(strings.ToLower(words[0]))
+		if sub == nil {
+			c.event.Outcome = eventlog.OutcomeBadArgs
+			return fmt.Sprintf("I don't have a `%s %s` command. here's what I do have:\n%s", sp.path(), strings.ToLower(words[0]), sp.describeSubcommands())
+		}
+		return sub.dispatch(c, words[1:], m, s)
+	}
+	if sp.run == nil {
+		return sp.describeSubcommands()
+	}
+
+	a, err := sp.parse(words)
+	if err != nil {
+		c.event.Outcome = eventlog.OutcomeBadArgs
+		return err.Error()
+	}
+	return sp.run(a, m, s)
+}
+
+// setParents points every subcommand at the command it's under.
+func (sp *commandSpec) setParents() {
+	for _, sub := range sp.subcommands {
+		sub.parent = sp
+		sub.setParents()
+	}
+}
@@ -0,0 +1,221 @@
+package bot
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestParse(t *testing.T) {
+	rank := &commandSpec{name: "rank", args: rankArgs(), rewrite: splitIVs}
+	want := &commandSpec{name: "want", args: []arg{{name: "pokemon", variadic: true, description: "the Pokemon to add"}}}
+	battle := (&PVP{}).spec()
+	battle.setParents()
+	config, ok := getCommand("config")
+	if !ok {
+		t.Fatal("config isn't registered")
+	}
+
+	tests := []struct {
+		name  string
+		sp    *commandSpec
+		words string
+		want  args
+		err   string
+	}{
+		{
+			name:  "rank",
+			sp:    rank,
+			words: "azumarill 4 1 3",
+			want:  args{"pokemon": []string{"azumarill"}, "atk": 4, "def": 1, "hp": 3},
+		},
+		{
+			name:  "rank with a league",
+			sp:    rank,
+			words: "ultra azumarill 4 1 3",
+			want:  args{"league": "ultra", "pokemon": []string{"azumarill"}, "atk": 4, "def": 1, "hp": 3},
+		},
+		{
+			name:  "rank with a league in capitals",
+			sp:    rank,
+			words: "Ultra Azumarill 4 1 3",
+			want:  args{"league": "ultra", "pokemon": []string{"azumarill"}, "atk": 4, "def": 1, "hp": 3},
+		},
+		{
+			name:  "rank with a Pokemon that's more than one word",
+			sp:    rank,
+			words: "deoxys defense 4 1 3",
+			want:  args{"pokemon": []string{"deoxys", "defense"}, "atk": 4, "def": 1, "hp": 3},
+		},
+		{
+			name:  "rank with a floor",
+			sp:    rank,
+			words: "azumarill 15 15 15 raid",
+			want:  args{"pokemon": []string{"azumarill"}, "atk": 15, "def": 15, "hp": 15, "floor": "raid"},
+		},
+		{
+			name:  "rank with slashes",
+			sp:    rank,
+			words: "azumarill 4/1/3",
+			want:  args{"pokemon": []string{"azumarill"}, "atk": 4, "def": 1, "hp": 3},
+		},
+		{
+			name:  "rank with slashes, a league and a floor",
+			sp:    rank,
+			words: "ultra deoxys defense 4/1/3 hatched",
+			want:  args{"league": "ultra", "pokemon": []string{"deoxys", "defense"}, "atk": 4, "def": 1, "hp": 3, "floor": "hatched"},
+		},
+		{
+			name:  "master isn't a league",
+			sp:    rank,
+			words: "master dialga 15 14 15",
+			want:  args{"pokemon": []string{"master", "dialga"}, "atk": 15, "def": 14, "hp": 15},
+		},
+		{
+			name:  "rank without anything",
+			sp:    rank,
+			words: "",
+			err:   "`rank` needs <pokemon...>: the Pokemon\nusage: `rank [league] <pokemon...> <atk> <def> <hp> [floor]` (`rank help` for more)",
+		},
+		{
+			name:  "rank without IVs",
+			sp:    rank,
+			words: "azumarill",
+			err:   "`rank` needs <atk>: its attack IV\nusage: `rank [league] <pokemon...> <atk> <def> <hp> [floor]` (`rank help` for more)",
+		},
+		{
+			name:  "rank with an IV out of range",
+			sp:    rank,
+			words: "azumarill 4 1 16",
+			err:   "hp should be between 0 and 15, not `16`\nusage: `rank [league] <pokemon...> <atk> <def> <hp> [floor]` (`rank help` for more)",
+		},
+		{
+			name:  "rank with an IV that isn't a number",
+			sp:    rank,
+			words: "azumarill a 1 3",
+			err:   "atk should be a number, not `a`\nusage: `rank [league] <pokemon...> <atk> <def> <hp> [floor]` (`rank help` for more)",
+		},
+		{
+			name:  "rank with something extra",
+			sp:    rank,
+			words: "azumarill 4 1 3 raid extra",
+			err:   "I don't know what `extra` is for\nusage: `rank [league] <pokemon...> <atk> <def> <hp> [floor]` (`rank help` for more)",
+		},
+		{
+			name:  "pvp battle",
+			sp:    battle.subcommand("battle"),
+			words: "",
+			want:  args{},
+		},
+		{
+			name:  "pvp battle with minutes",
+			sp:    battle.subcommand("battle"),
+			words: "60",
+			want:  args{"minutes": "60"},
+		},
+		{
+			name:  "pvp battle stop",
+			sp:    battle.subcommand("battle"),
+			words: "stop",
+			want:  args{"minutes": "stop"},
+		},
+		{
+			name:  "pvp battle with something extra",
+			sp:    battle.subcommand("battle"),
+			words: "60 now",
+			err:   "I don't know what `now` is for\nusage: `pvp battle [minutes]` (`pvp battle help` for more)",
+		},
+		{
+			name:  "config bool",
+			sp:    config.subcommand("roles"),
+			words: "off",
+			want:  args{"roles": false},
+		},
+		{
+			name:  "config bool as yes",
+			sp:    config.subcommand("roles"),
+			words: "yes",
+			want:  args{"roles": true},
+		},
+		{
+			name:  "config bool that isn't one",
+			sp:    config.subcommand("roles"),
+			words: "maybe",
+			err:   "roles should be `on` or `off`, not `maybe`\nusage: `config roles <roles>` (`config roles help` for more)",
+		},
+		{
+			name:  "config channel",
+			sp:    config.subcommand("pvp"),
+			words: "<#123>",
+			want:  args{"channel": "123"},
+		},
+		{
+			name:  "config channel cleared",
+			sp:    config.subcommand("pvp"),
+			words: "none",
+			want:  args{"channel": ""},
+		},
+		{
+			name:  "config channel that isn't one",
+			sp:    config.subcommand("pvp"),
+			words: "pvp",
+			err:   "channel should be a channel (ie `#general`) or `none`, not `pvp`\nusage: `config pvp <channel>` (`config pvp help` for more)",
+		},
+		{
+			name:  "config prefix cleared",
+			sp:    config.subcommand("prefix"),
+			words: "none",
+			want:  args{"prefix": ""},
+		},
+		{
+			name:  "config choice",
+			sp:    config.subcommand("league"),
+			words: "master",
+			err:   "league should be `great` or `ultra`, not `master`\nusage: `config league <league>` (`config league help` for more)",
+		},
+		{
+			name:  "variadic",
+			sp:    want,
+			words: "shieldon bagon",
+			want:  args{"pokemon": []string{"shieldon", "bagon"}},
+		},
+		{
+			name:  "variadic without any",
+			sp:    want,
+			words: "",
+			err:   "`want` needs <pokemon...>: the Pokemon to add\nusage: `want <pokemon...>` (`want help` for more)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var words []string
+			if tt.words != "" {
+				words = strings.Split(tt.words, " ")
+			}
+			got, err := tt.sp.parse(words)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Errorf("got error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRankMaster(t *testing.T) {
+	a := args{"pokemon": []string{"master", "dialga"}, "atk": 15, "def": 14, "hp": 15}
+	m := &discordgo.MessageCreate{Message: &discordgo.Message{}}
+	if got := getRank(a, m, true, false); got != "sorry, only `great` and `ultra` are supported" {
+		t.Errorf("got %q", got)
+	}
+}
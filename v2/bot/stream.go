@@ -0,0 +1,139 @@
+package bot
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Sigafoos/wobbotfet/backend"
+	"github.com/Sigafoos/wobbotfet/eventlog"
+)
+
+// Types of StreamEvent.
+const (
+	// StreamCommand is a handled command. Its Data is an eventlog.Event.
+	StreamCommand = "command"
+	// StreamBackendError is a failed service call. Its Data is a BackendError.
+	StreamBackendError = "backend_error"
+	// StreamPanic is a recovered panic. Its Data is a Panic.
+	StreamPanic = "panic"
+	// StreamConversation is a PM conversation changing state. Its Data is a Conversation.
+	StreamConversation = "conversation"
+)
+
+// streamBuffer is how many events a subscriber can fall behind by before events are dropped for them.
+const streamBuffer = 64
+
+// A StreamEvent is something that happened, for watching live.
+type StreamEvent struct {
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	// Guild is empty for things that happened in a PM.
+	Guild string      `json:"guild,omitempty"`
+	Data  interface{} `json:"data"`
+}
+
+// A BackendError is a service call that failed: the service didn't respond, or responded with a server error.
+type BackendError struct {
+	Service   string `json:"service"`
+	Method    string `json:"method"`
+	Path      string `json:"path"`
+	RequestID string `json:"request_id"`
+	// Status is the status code, or why there wasn't one.
+	Status   string  `json:"status"`
+	Attempts int     `json:"attempts"`
+	Latency  float64 `json:"latency_ms"`
+}
+
+// A Panic is a command handler that panicked. The stack trace is only sent to the owner.
+type Panic struct {
+	Channel string `json:"channel"`
+	User    string `json:"user"`
+	Command string `json:"command"`
+	Error   string `json:"error"`
+}
+
+// Conversation states.
+const (
+	// ConversationWaiting means wobbotfet asked something in a PM and is waiting on the reply.
+	ConversationWaiting = "waiting"
+	// ConversationReplied means the reply came in.
+	ConversationReplied = "replied"
+	// ConversationCleared means the owner told wobbotfet to stop waiting.
+	ConversationCleared = "cleared"
+	// ConversationExpired means the question went unanswered for too long.
+	ConversationExpired = "expired"
+)
+
+// A Conversation is a PM conversation changing state.
+type Conversation struct {
+	Channel string `json:"channel"`
+	State   string `json:"state"`
+}
+
+// subscribers get every event published (for their guild, if they have one).
+var subscribers = struct {
+	sync.Mutex
+	chans map[chan StreamEvent]string
+}{chans: make(map[chan StreamEvent]string)}
+
+// Subscribe returns a channel of everything that happens from now on, and a function to call when you're done with
+// it. If guild isn't empty, it's only what happens in that guild. Slow subscribers miss events rather than hold up
+// the bot.
+func (b *Bot) Subscribe(guild string) (<-chan StreamEvent, func()) {
+	ch := make(chan StreamEvent, streamBuffer)
+	subscribers.Lock()
+	subscribers.chans[ch] = guild
+	subscribers.Unlock()
+
+	return ch, func() {
+		subscribers.Lock()
+		defer subscribers.Unlock()
+		if _, ok := subscribers.chans[ch]; ok {
+			delete(subscribers.chans, ch)
+			close(ch)
+		}
+	}
+}
+
+func publish(typ, guild string, data interface{}) {
+	e := StreamEvent{
+		Type:  typ,
+		Time:  time.Now(),
+		Guild: guild,
+		Data:  data,
+	}
+
+	subscribers.Lock()
+	defer subscribers.Unlock()
+	for ch, g := range subscribers.chans {
+		if g != "" && g != guild {
+			continue
+		}
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// publishCommand streams a logged event. It gets a copy, since the event's done with by then.
+func publishCommand(e *eventlog.Event) {
+	event := *e
+	publish(StreamCommand, e.Guild, &event)
+}
+
+// publishBackendError is a backend.Observer that streams failed service calls.
+func publishBackendError(r backend.Result) {
+	if r.Err == nil && r.StatusCode < 500 {
+		return
+	}
+	publish(StreamBackendError, requestGuild(r.RequestID), &BackendError{
+		Service:   r.Service,
+		Method:    r.Method,
+		Path:      r.Path,
+		RequestID: r.RequestID,
+		Status:    backendStatus(r),
+		Attempts:  r.Attempts,
+		Latency:   float64(r.Duration.Microseconds()) / 1000,
+	})
+}
@@ -0,0 +1,96 @@
+package bot
+
+import (
+	"sort"
+)
+
+// maxSuggestions is the most commands suggested for one that doesn't exist.
+const maxSuggestions = 3
+
+// suggestCommands returns the commands (or aliases) that word is probably a typo of, closest first. Commands that are
+// turned off, everywhere or in the guild, aren't suggested.
+func suggestCommands(word, guild string) []string {
+	c := guildConfig(guild)
+	// short words are close to too much
+	limit := 1
+	if len(word) > 4 {
+		limit = 2
+	}
+
+	type suggestion struct {
+		name     string
+		distance int
+		order    int
+	}
+	best := make(map[string]suggestion)
+	for i, name := range commandNames() {
+		sp, ok := getCommand(name)
+		if !ok || !featureEnabled(name) || !c.Enabled(name) {
+			continue
+		}
+		for _, candidate := range append([]string{sp.name}, sp.aliases...) {
+			d := editDistance(word, candidate)
+			if d > limit {
+				continue
+			}
+			if s, ok := best[name]; !ok || d < s.distance {
+				best[name] = suggestion{name: candidate, distance: d, order: i}
+			}
+		}
+	}
+
+	suggestions := make([]suggestion, 0, len(best))
+	for _, s := range best {
+		suggestions = append(suggestions, s)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].distance != suggestions[j].distance {
+			return suggestions[i].distance < suggestions[j].distance
+		}
+		return suggestions[i].order < suggestions[j].order
+	})
+
+	var names []string
+	for i := 0; i < len(suggestions) && i < maxSuggestions; i++ {
+		names = append(names, suggestions[i].name)
+	}
+	return names
+}
+
+// editDistance is how many letters have to be added, removed, changed or swapped with the next one to turn a into b,
+// so `wnat` is 1 from `want`.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	// d[i][j] is the distance between the first i letters of a and the first j of b
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+func min(first int, rest ...int) int {
+	for _, n := range rest {
+		if n < first {
+			first = n
+		}
+	}
+	return first
+}
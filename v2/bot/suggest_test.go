@@ -0,0 +1,70 @@
+package bot
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Sigafoos/wobbotfet/config"
+)
+
+func TestSuggestCommands(t *testing.T) {
+	setupWant(config.Service{URL: "http://localhost"})
+	defer func() {
+		for _, name := range []string{"want", "unwant", "wants", "search"} {
+			unregisterCommand(name)
+		}
+		servicesMu.Lock()
+		wantClient = nil
+		servicesMu.Unlock()
+	}()
+
+	tests := []struct {
+		word string
+		want []string
+	}{
+		{word: "wnat", want: []string{"want"}},
+		{word: "hlep", want: []string{"help"}},
+		{word: "confg", want: []string{"config"}},
+		{word: "unwnat", want: []string{"unwant"}},
+		// closest first
+		{word: "wnats", want: []string{"wants", "want"}},
+		// aliases are suggested as they were typed
+		{word: "fnd", want: []string{"find"}},
+		{word: "comands", want: []string{"commands"}},
+		// too far from anything
+		{word: "xyz"},
+		{word: "w"},
+		{word: "pokemon"},
+	}
+	for _, tt := range tests {
+		if got := suggestCommands(tt.word, ""); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("suggestCommands(%q) = %v, want %v", tt.word, got, tt.want)
+		}
+	}
+
+	setupFeatures([]string{"want"})
+	defer setupFeatures(nil)
+	if got := suggestCommands("wnat", ""); got != nil {
+		t.Errorf("suggested %v for a command that's turned off", got)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"want", "want", 0},
+		{"wnat", "want", 1},
+		{"wan", "want", 1},
+		{"wants", "want", 1},
+		{"wamt", "want", 1},
+		{"", "want", 4},
+		{"rank", "want", 2},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
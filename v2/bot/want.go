@@ -1,89 +1,50 @@
 package bot
 
 import (
-	"bytes"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
 	"log"
-	"net/http"
-	"os"
 	"strings"
 
-	"github.com/Sigafoos/pokemongo"
+	"github.com/Sigafoos/wobbotfet/config"
+	"github.com/Sigafoos/wobbotfet/wantclient"
 	"github.com/bwmarrin/discordgo"
 )
 
-type Request struct {
-	User    string `json:"user,omitempty"`
-	Pokemon string `json:"pokemon,omitempty"`
-}
-
-var (
-	wantURL   = os.Getenv("WANT_URL")
-	basicuser = os.Getenv("WANT_BASICUSER")
-	basicpass = os.Getenv("WANT_BASICPASS")
-)
+var wantClient *wantclient.Client
 
-const applicationJSON = "application/json"
+// wants returns the want service's client.
+func wants() *wantclient.Client {
+	servicesMu.RLock()
+	defer servicesMu.RUnlock()
+	return wantClient
+}
 
 const errorForbidden = "HTTP 403 Forbidden"
 
 // this can probably be abstracted into modifyWants or something; just have to handle errors
-func want(pieces []string, m *discordgo.MessageCreate, s *discordgo.Session) string {
-	if len(pieces) == 0 {
-		return "you need to specify one or more Pokemon (to see wants, use `wants`)"
-	}
-
+func want(a args, m *discordgo.MessageCreate, s *discordgo.Session) string {
+	ctx := requestContext(m)
 	var succeeded []string
 	var failed []string
 	var roleFailed []string
-	for _, w := range pieces {
+	for _, w := range a.Strings("pokemon") {
 		formattedName := "`" + w + "`"
-		access.Printf("%s\t%s\t%s\twant\t%s\n", m.GuildID, m.ChannelID, m.Author.String(), w)
-		b, err := json.Marshal(&Request{User: m.Author.ID, Pokemon: w})
-		if err != nil {
-			log.Println(err)
-			failed = append(failed, formattedName)
+		err := wants().Want(ctx, m.Author.ID, w)
+		if errors.Is(err, wantclient.ErrNotFound) {
+			failed = append(failed, formattedName+" (no such Pokemon)")
 			continue
 		}
-
-		req, err := http.NewRequest(http.MethodPost, wantURL+"/want", bytes.NewReader(b))
-		if err != nil {
-			log.Println(err)
-			failed = append(failed, formattedName)
+		if errors.Is(err, wantclient.ErrConflict) {
+			failed = append(failed, formattedName+" (already wanted)")
 			continue
 		}
-		req.Header.Add("Content-Type", applicationJSON)
-		req.Header.Add("Accept", applicationJSON)
-		if basicuser != "" && basicpass != "" {
-			req.SetBasicAuth(basicuser, basicpass)
-		}
-
-		response, err := client.Do(req)
 		if err != nil {
-			log.Println(err)
-			failed = append(failed, formattedName)
-			continue
-		}
-		if response.Body != nil {
-			defer response.Body.Close()
-		}
-
-		if response.StatusCode == http.StatusNotFound {
-			failed = append(failed, formattedName+" (no such Pokemon)")
-			continue
-		}
-
-		if response.StatusCode == http.StatusConflict {
-			failed = append(failed, formattedName+" (already wanted)")
+			// the rest might work, and the user should hear about the ones that did
+			failed = append(failed, formattedName+" ("+errorMessage(m, err)+")")
 			continue
 		}
 
-		if response.StatusCode != http.StatusCreated {
-			log.Printf("error creating want: got %v\n", response.StatusCode)
-			return "uh oh, something went wrong"
-		}
 		succeeded = append(succeeded, formattedName)
 		if err := addRole(w, m, s); err != nil {
 			roleFailed = append(roleFailed, formattedName)
@@ -103,42 +64,15 @@ func want(pieces []string, m *discordgo.MessageCreate, s *discordgo.Session) str
 		if len(message) > 0 {
 			message += "\n\n"
 		}
-		message += "failed adding roles: " + strings.Join(failed, ", ")
+		message += "failed adding roles: " + strings.Join(roleFailed, ", ")
 	}
 	return message
 }
 
-func listWants(pieces []string, m *discordgo.MessageCreate, s *discordgo.Session) string {
-	access.Printf("%s\t%s\t%s\twants\n", m.GuildID, m.ChannelID, m.Author.String())
-	req, err := http.NewRequest(http.MethodGet, wantURL+"/want?user="+m.Author.ID, nil)
-	if err != nil {
-		log.Println(err)
-		return "uh oh, something's gone wrong"
-	}
-	req.Header.Add("Content-Type", applicationJSON)
-	req.Header.Add("Accept", applicationJSON)
-	if basicuser != "" && basicpass != "" {
-		req.SetBasicAuth(basicuser, basicpass)
-	}
-
-	response, err := client.Do(req)
-	if err != nil {
-		log.Println(err)
-		return "uh oh, something's gone wrong"
-	}
-	defer response.Body.Close()
-
-	b, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		log.Println(err)
-		return "sorry, something's gone wrong"
-	}
-
-	var pokemon []pokemongo.Pokemon
-	err = json.Unmarshal(b, &pokemon)
-	if err != nil {
-		log.Println(err)
-		return "sorry, something's gone wrong"
+func listWants(a args, m *discordgo.MessageCreate, s *discordgo.Session) string {
+	pokemon, err := wants().Wants(requestContext(m), m.Author.ID)
+	if err != nil && !errors.Is(err, wantclient.ErrNotFound) {
+		return errorMessage(m, err)
 	}
 
 	if len(pokemon) == 0 {
@@ -155,50 +89,22 @@ func listWants(pieces []string, m *discordgo.MessageCreate, s *discordgo.Session
 	return "your wants: `" + strings.Join(names, "`, `") + "`"
 }
 
-func unwant(pieces []string, m *discordgo.MessageCreate, s *discordgo.Session) string {
+func unwant(a args, m *discordgo.MessageCreate, s *discordgo.Session) string {
+	ctx := requestContext(m)
 	var succeeded []string
 	var failed []string
-	for _, w := range pieces {
+	for _, w := range a.Strings("pokemon") {
 		formattedName := "`" + w + "`"
-		access.Printf("%s\t%s\t%s\tunwant\t%s", m.GuildID, m.ChannelID, m.Author.String(), w)
-		b, err := json.Marshal(&Request{User: m.Author.ID, Pokemon: w})
-		if err != nil {
-			log.Println(err)
-			failed = append(failed, formattedName)
-			continue
-		}
-
-		req, err := http.NewRequest(http.MethodDelete, wantURL+"/want", bytes.NewReader(b))
-		if err != nil {
-			log.Println(err)
-			failed = append(failed, formattedName)
+		err := wants().Unwant(ctx, m.Author.ID, w)
+		if errors.Is(err, wantclient.ErrNotFound) {
+			failed = append(failed, formattedName+" (no such Pokemon)")
 			continue
 		}
-		req.Header.Add("Content-Type", applicationJSON)
-		req.Header.Add("Accept", applicationJSON)
-		if basicuser != "" && basicpass != "" {
-			req.SetBasicAuth(basicuser, basicpass)
-		}
-
-		response, err := client.Do(req)
 		if err != nil {
-			log.Println(err)
-			failed = append(failed, formattedName)
+			failed = append(failed, formattedName+" ("+errorMessage(m, err)+")")
 			continue
 		}
-		if response.Body != nil {
-			defer response.Body.Close()
-		}
 
-		if response.StatusCode == http.StatusNotFound {
-			failed = append(failed, formattedName+" (no such Pokemon)")
-			continue
-		}
-
-		if response.StatusCode != http.StatusOK {
-			log.Printf("error creating want: got %v\n", response.StatusCode)
-			return "uh oh, something went wrong"
-		}
 		succeeded = append(succeeded, formattedName)
 		removeRole(w, m, s)
 	}
@@ -215,45 +121,11 @@ func unwant(pieces []string, m *discordgo.MessageCreate, s *discordgo.Session) s
 	return message
 }
 
-func searchForPokemon(pieces []string, m *discordgo.MessageCreate, s *discordgo.Session) string {
-	if len(pieces) < 1 {
-		return "I need a Pokemon to search for!"
-	}
-	if len(pieces) > 1 {
-		return "you can only search for one Pokemon at a time"
-	}
-
-	access.Printf("%s\t%s\t%s\tsearch\t%s\n", m.GuildID, m.ChannelID, m.Author.String(), pieces[0])
-	req, err := http.NewRequest(http.MethodGet, wantURL+"/search?name="+pieces[0], nil)
-	if err != nil {
-		log.Println(err)
-		return "uh oh, something's gone wrong"
-	}
-
-	req.Header.Add("Content-Type", applicationJSON)
-	req.Header.Add("Accept", applicationJSON)
-	if basicuser != "" && basicpass != "" {
-		req.SetBasicAuth(basicuser, basicpass)
-	}
-
-	response, err := client.Do(req)
-	if err != nil {
-		log.Println(err)
-		return "uh oh, something's gone wrong"
-	}
-	defer response.Body.Close()
-
-	b, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		log.Println(err)
-		return "sorry, something's gone wrong"
-	}
-
-	var pokemon []pokemongo.Pokemon
-	err = json.Unmarshal(b, &pokemon)
-	if err != nil {
-		log.Println(err)
-		return "sorry, something's gone wrong"
+func searchForPokemon(a args, m *discordgo.MessageCreate, s *discordgo.Session) string {
+	query := a.String("pokemon")
+	pokemon, err := wants().Search(requestContext(m), query)
+	if err != nil && !errors.Is(err, wantclient.ErrNotFound) {
+		return errorMessage(m, err)
 	}
 
 	var names []string
@@ -261,7 +133,7 @@ func searchForPokemon(pieces []string, m *discordgo.MessageCreate, s *discordgo.
 		names = append(names, p.ID)
 	}
 
-	return fmt.Sprintf("`%s` matches: %s", pieces[0], strings.Join(names, ", "))
+	return fmt.Sprintf("`%s` matches: %s", query, strings.Join(names, ", "))
 }
 
 // add a role to a user. creates it if it doesn't exist. on error, log it and silently return.
@@ -273,24 +145,30 @@ func addRole(roleName string, m *discordgo.MessageCreate, s *discordgo.Session)
 		return nil
 	}
 
+	c := guildConfig(m.GuildID)
+	roleName = c.RolePrefix + roleName
+
 	var err error
 	role := getRole(roleName, m.GuildID, s)
 	if role == nil {
+		if !c.CreateRoles {
+			return nil
+		}
 		role, err = s.GuildRoleCreate(m.GuildID)
 		if err != nil {
 			// don't log if the admin just hasn't granted permissions
 			if !strings.HasPrefix(err.Error(), errorForbidden) {
-				log.Printf("error creating roleName in guild %s: %s\n", m.GuildID, err.Error())
+				logError(m, "error creating roleName in guild %s: %s", m.GuildID, err)
 			}
 			return err
 		}
 		_, err = s.GuildRoleEdit(m.GuildID, role.ID, roleName, 0, false, 0, true)
 		if err != nil {
-			log.Printf("error updating roleName %s in guild %s: %s\n", roleName, m.GuildID, err.Error())
+			logError(m, "error updating roleName %s in guild %s: %s", roleName, m.GuildID, err)
 
 			deleteErr := s.GuildRoleDelete(m.GuildID, role.ID)
 			if deleteErr != nil {
-				log.Printf("error deleting roleName %s in guild %s: %s\n", roleName, m.GuildID, err.Error())
+				logError(m, "error deleting roleName %s in guild %s: %s", roleName, m.GuildID, deleteErr)
 			}
 			return err
 		}
@@ -298,7 +176,7 @@ func addRole(roleName string, m *discordgo.MessageCreate, s *discordgo.Session)
 
 	err = s.GuildMemberRoleAdd(m.GuildID, m.Author.ID, role.ID)
 	if err != nil {
-		log.Printf("error adding roleName %s to user %s in guild %s: %s\n", roleName, m.Author.Username, m.GuildID, err.Error())
+		logError(m, "error adding roleName %s to user %s in guild %s: %s", roleName, m.Author.Username, m.GuildID, err)
 		return err
 	}
 
@@ -311,7 +189,7 @@ func removeRole(roleName string, m *discordgo.MessageCreate, s *discordgo.Sessio
 		return
 	}
 
-	role := getRole(roleName, m.GuildID, s)
+	role := getRole(guildConfig(m.GuildID).RolePrefix+roleName, m.GuildID, s)
 	if role == nil {
 		return
 	}
@@ -320,7 +198,7 @@ func removeRole(roleName string, m *discordgo.MessageCreate, s *discordgo.Sessio
 	if err != nil {
 		// don't log if the admin just hasn't granted permissions
 		if !strings.HasPrefix(err.Error(), errorForbidden) {
-			log.Printf("error removing role %s from user %s in guild %s: %s\n", roleName, m.Author.Username, m.GuildID, err.Error())
+			logError(m, "error removing role %s from user %s in guild %s: %s", roleName, m.Author.Username, m.GuildID, err)
 		}
 		return
 	}
@@ -329,7 +207,7 @@ func removeRole(roleName string, m *discordgo.MessageCreate, s *discordgo.Sessio
 func getRole(roleName, guildID string, s *discordgo.Session) *discordgo.Role {
 	roles, err := s.GuildRoles(guildID)
 	if err != nil {
-		log.Printf("error geetting roles for guild %s: %s\n", guildID, err.Error())
+		logError(nil, "error getting roles for guild %s: %s", guildID, err)
 		return nil
 	}
 
@@ -344,7 +222,7 @@ func getRole(roleName, guildID string, s *discordgo.Session) *discordgo.Role {
 func syncRoles(m *discordgo.MessageCreate, wants []string, s *discordgo.Session) {
 	user, err := s.GuildMember(m.GuildID, m.Author.ID)
 	if err != nil {
-		log.Printf("error getting guild member: %s", err)
+		logError(m, "error getting guild member: %s", err)
 		return
 	}
 	userRoles := make(map[string]bool)
@@ -356,7 +234,7 @@ func syncRoles(m *discordgo.MessageCreate, wants []string, s *discordgo.Session)
 	roleNameMap := make(map[string]*discordgo.Role)
 	roles, err := s.GuildRoles(m.GuildID)
 	if err != nil {
-		log.Printf("error getting guild roles: %s", err)
+		logError(m, "error getting guild roles: %s", err)
 		return
 	}
 	for _, v := range roles {
@@ -364,9 +242,11 @@ func syncRoles(m *discordgo.MessageCreate, wants []string, s *discordgo.Session)
 		roleNameMap[v.Name] = v
 	}
 
+	prefix := guildConfig(m.GuildID).RolePrefix
+
 	// add any roles the user is missing
 	for _, want := range wants {
-		wantedRole, exists := roleNameMap[want]
+		wantedRole, exists := roleNameMap[prefix+want]
 		if !exists {
 			// role doesn't exist on the server, so clearly they don't have it
 			if err := addRole(want, m, s); err != nil {
@@ -388,13 +268,40 @@ func syncRoles(m *discordgo.MessageCreate, wants []string, s *discordgo.Session)
 	// remove any roles the user should no longer have
 }
 
-func init() {
-	if wantURL == "" {
-		log.Println("no WANT_URL specified; cannot run want command")
+// setupWant registers the want commands, if the want service is configured.
+func setupWant(c config.Service) {
+	if c.URL == "" {
 		return
 	}
-	registerCommand("want", want, "`want wobbuffet` to add to your wants. specify multiple separated by spaces (no commas).")
-	registerCommand("unwant", unwant, "`unwant wobbuffet` to remove from your wants")
-	registerCommand("wants", listWants, "list your wants. will also sync wants/roles between servers.")
-	registerCommand("search", searchForPokemon, "search for Pokemon by name")
+	client := wantclient.New(c.URL, serviceOptions(c)...)
+	servicesMu.Lock()
+	wantClient = client
+	servicesMu.Unlock()
+	registerCommand(&commandSpec{
+		name:        "want",
+		description: "add to your wants. specify multiple separated by spaces (no commas).",
+		examples:    []string{"want wobbuffet", "want shieldon bagon"},
+		args:        []arg{{name: "pokemon", variadic: true, description: "the Pokemon to add, separated by spaces"}},
+		run:         want,
+	})
+	registerCommand(&commandSpec{
+		name:        "unwant",
+		description: "remove from your wants",
+		examples:    []string{"unwant wobbuffet"},
+		args:        []arg{{name: "pokemon", variadic: true, description: "the Pokemon to remove, separated by spaces"}},
+		run:         unwant,
+	})
+	registerCommand(&commandSpec{
+		name:        "wants",
+		description: "list your wants. will also sync wants/roles between servers.",
+		run:         listWants,
+	})
+	registerCommand(&commandSpec{
+		name:        "search",
+		aliases:     []string{"find"},
+		description: "search for Pokemon by name",
+		examples:    []string{"search deoxys"},
+		args:        []arg{{name: "pokemon", description: "the name, or part of it"}},
+		run:         searchForPokemon,
+	})
 }
@@ -0,0 +1,416 @@
+// apigen generates the apiclient package's types and methods from the dashboard API's OpenAPI spec. It only knows
+// about as much OpenAPI as the spec uses. Run it with go generate in apiclient.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/Sigafoos/wobbotfet/api"
+)
+
+type spec struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas    map[string]*schema    `json:"schemas"`
+		Parameters map[string]*parameter `json:"parameters"`
+	} `json:"components"`
+}
+
+type schema struct {
+	Ref                  string          `json:"$ref"`
+	Type                 string          `json:"type"`
+	Format               string          `json:"format"`
+	Description          string          `json:"description"`
+	Items                *schema         `json:"items"`
+	Properties           properties      `json:"properties"`
+	AdditionalProperties json.RawMessage `json:"additionalProperties"`
+}
+
+// properties are a schema's properties in the order they're in the spec, so the structs are too.
+type properties struct {
+	names   []string
+	schemas map[string]*schema
+}
+
+func (p *properties) UnmarshalJSON(b []byte) error {
+	p.schemas = make(map[string]*schema)
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		name := t.(string)
+		var s schema
+		if err := dec.Decode(&s); err != nil {
+			return err
+		}
+		p.names = append(p.names, name)
+		p.schemas[name] = &s
+	}
+	return nil
+}
+
+type parameter struct {
+	Ref         string  `json:"$ref"`
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description"`
+	Schema      *schema `json:"schema"`
+}
+
+type operation struct {
+	OperationID string       `json:"operationId"`
+	Summary     string       `json:"summary"`
+	Parameters  []*parameter `json:"parameters"`
+	RequestBody *struct {
+		Content map[string]struct {
+			Schema *schema `json:"schema"`
+		} `json:"content"`
+	} `json:"requestBody"`
+	Responses map[string]struct {
+		Content map[string]struct {
+			Schema *schema `json:"schema"`
+		} `json:"content"`
+	} `json:"responses"`
+}
+
+// methods are the HTTP methods in the order their operations are generated.
+var methods = []string{"get", "put", "post", "delete"}
+
+// initialisms are written in capitals in Go names.
+var initialisms = map[string]string{"id": "ID", "ign": "IGN", "url": "URL", "api": "API", "dm": "DM", "pms": "PMs", "ms": "MS", "pvp": "PVP"}
+
+func main() {
+	out := flag.String("o", "generated.go", "the file to write")
+	flag.Parse()
+
+	src, err := generate()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(*out, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// generate returns the source of the client for api.Spec.
+func generate() ([]byte, error) {
+	var s spec
+	if err := json.Unmarshal([]byte(api.Spec), &s); err != nil {
+		return nil, fmt.Errorf("error decoding spec: %w", err)
+	}
+
+	var b bytes.Buffer
+
+	names := make([]string, 0, len(s.Components.Schemas))
+	for name := range s.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		writeStruct(&b, name, s.Components.Schemas[name])
+	}
+
+	paths := make([]string, 0, len(s.Paths))
+	for path := range s.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var skipped []string
+	for _, path := range paths {
+		item := s.Paths[path]
+		var shared []*parameter
+		if raw, ok := item["parameters"]; ok {
+			if err := json.Unmarshal(raw, &shared); err != nil {
+				return nil, fmt.Errorf("error decoding %s parameters: %w", path, err)
+			}
+		}
+		for _, method := range methods {
+			raw, ok := item[method]
+			if !ok {
+				continue
+			}
+			var op operation
+			if err := json.Unmarshal(raw, &op); err != nil {
+				return nil, fmt.Errorf("error decoding %s %s: %w", method, path, err)
+			}
+			if !writeOperation(&b, &s, path, method, append(append([]*parameter{}, shared...), op.Parameters...), &op) {
+				skipped = append(skipped, strings.ToUpper(method)+" "+path)
+			}
+		}
+	}
+	if len(skipped) > 0 {
+		fmt.Fprintf(&b, "// These don't respond with JSON, so there's no method for them:\n//\n")
+		for _, s := range skipped {
+			fmt.Fprintf(&b, "//  %s\n", s)
+		}
+	}
+
+	// only import what the spec needs
+	var header bytes.Buffer
+	header.WriteString("// Code generated by apigen from the dashboard API's OpenAPI spec. DO NOT EDIT.\n\n")
+	header.WriteString("package apiclient\n\nimport (\n")
+	for _, pkg := range []string{"context", "encoding/json", "net/http", "net/url", "strconv", "time"} {
+		if strings.Contains(b.String(), pkg[strings.LastIndex(pkg, "/")+1:]+".") {
+			fmt.Fprintf(&header, "%q\n", pkg)
+		}
+	}
+	header.WriteString(")\n\n")
+	header.Write(b.Bytes())
+
+	src, err := format.Source(header.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("error formatting generated code: %w\n%s", err, header.String())
+	}
+	return src, nil
+}
+
+func writeStruct(b *bytes.Buffer, name string, s *schema) {
+	if s.Description != "" {
+		fmt.Fprintf(b, "// %s is %s.\n", name, lowerFirst(s.Description))
+	} else {
+		fmt.Fprintf(b, "// %s is the %s schema.\n", name, name)
+	}
+	fmt.Fprintf(b, "type %s struct {\n", name)
+	for _, prop := range s.Properties.names {
+		p := s.Properties.schemas[prop]
+		if p.Description != "" {
+			fmt.Fprintf(b, "// %s\n", p.Description)
+		}
+		fmt.Fprintf(b, "%s %s `json:%q`\n", goName(prop), goType(p), prop)
+	}
+	b.WriteString("}\n\n")
+}
+
+// writeOperation writes the method for an operation, returning false if it can't be generated.
+func writeOperation(b *bytes.Buffer, s *spec, path, method string, params []*parameter, op *operation) bool {
+	// the first success is the response
+	var codes []string
+	for code := range op.Responses {
+		if strings.HasPrefix(code, "2") {
+			codes = append(codes, code)
+		}
+	}
+	if len(codes) == 0 {
+		return false
+	}
+	sort.Strings(codes)
+	status := codes[0]
+	response := op.Responses[status]
+	var out *schema
+	if len(response.Content) > 0 {
+		content, ok := response.Content["application/json"]
+		if !ok {
+			return false
+		}
+		out = content.Schema
+		if out == nil {
+			out = &schema{}
+		}
+	}
+
+	name := goName(op.OperationID)
+	var args, pathArgs, query []string
+	args = append(args, "ctx context.Context")
+	for _, p := range params {
+		if p.Ref != "" {
+			p = s.Components.Parameters[strings.TrimPrefix(p.Ref, "#/components/parameters/")]
+		}
+		switch p.In {
+		case "path":
+			args = append(args, lowerFirst(goName(p.Name))+" string")
+			pathArgs = append(pathArgs, p.Name)
+		case "query":
+			query = append(query, p.Name)
+		}
+	}
+
+	// query parameters go in a struct, since they're optional
+	if len(query) > 0 {
+		fmt.Fprintf(b, "// %sParams are the optional query parameters for %s.\n", name, name)
+		fmt.Fprintf(b, "type %sParams struct {\n", name)
+		for _, q := range query {
+			p := findParameter(s, params, q)
+			if p.Description != "" {
+				fmt.Fprintf(b, "// %s\n", p.Description)
+			}
+			fmt.Fprintf(b, "%s %s\n", goName(q), goType(p.Schema))
+		}
+		b.WriteString("}\n\n")
+		args = append(args, "params *"+name+"Params")
+	}
+
+	body := "nil"
+	if op.RequestBody != nil {
+		if content, ok := op.RequestBody.Content["application/json"]; ok && content.Schema != nil {
+			args = append(args, "body *"+goType(content.Schema))
+			body = "body"
+		}
+	}
+
+	returns := "error"
+	if out != nil {
+		returns = "(" + returnType(out) + ", error)"
+	}
+
+	fmt.Fprintf(b, "// %s calls %s %s: %s.\n", name, strings.ToUpper(method), path, lowerFirst(op.Summary))
+	fmt.Fprintf(b, "func (c *Client) %s(%s) %s {\n", name, strings.Join(args, ", "), returns)
+
+	pathExpr := fmt.Sprintf("%q", path)
+	for _, p := range pathArgs {
+		pathExpr = strings.Replace(pathExpr, "{"+p+"}", "\" + url.PathEscape("+lowerFirst(goName(p))+") + \"", 1)
+	}
+	pathExpr = strings.TrimSuffix(strings.TrimPrefix(pathExpr, "\"\" + "), " + \"\"")
+
+	if len(query) > 0 {
+		b.WriteString("query := url.Values{}\nif params != nil {\n")
+		for _, q := range query {
+			p := findParameter(s, params, q)
+			field := "params." + goName(q)
+			switch goType(p.Schema) {
+			case "bool":
+				fmt.Fprintf(b, "if %s {\nquery.Set(%q, \"true\")\n}\n", field, q)
+			case "int":
+				fmt.Fprintf(b, "if %s != 0 {\nquery.Set(%q, strconv.Itoa(%s))\n}\n", field, q, field)
+			default:
+				fmt.Fprintf(b, "if %s != \"\" {\nquery.Set(%q, %s)\n}\n", field, q, field)
+			}
+		}
+		b.WriteString("}\n")
+	} else {
+		b.WriteString("var query url.Values\n")
+	}
+
+	if out == nil {
+		fmt.Fprintf(b, "return c.do(ctx, http.Method%s, %s, query, %s, nil, %s)\n}\n\n", strings.Title(method), pathExpr, body, status)
+		return true
+	}
+	fmt.Fprintf(b, "var out %s\n", goType(out))
+	fmt.Fprintf(b, "if err := c.do(ctx, http.Method%s, %s, query, %s, &out, %s); err != nil {\nreturn %s, err\n}\n", strings.Title(method), pathExpr, body, status, zero(out))
+	if strings.HasPrefix(returnType(out), "*") {
+		b.WriteString("return &out, nil\n}\n\n")
+	} else {
+		b.WriteString("return out, nil\n}\n\n")
+	}
+	return true
+}
+
+func findParameter(s *spec, params []*parameter, name string) *parameter {
+	for _, p := range params {
+		if p.Ref != "" {
+			p = s.Components.Parameters[strings.TrimPrefix(p.Ref, "#/components/parameters/")]
+		}
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+func goType(s *schema) string {
+	if s == nil {
+		return "json.RawMessage"
+	}
+	if s.Ref != "" {
+		return s.Ref[strings.LastIndex(s.Ref, "/")+1:]
+	}
+	switch s.Type {
+	case "string":
+		if s.Format == "date-time" {
+			return "time.Time"
+		}
+		return "string"
+	case "integer":
+		return "int"
+	case "number":
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		return "[]" + goType(s.Items)
+	case "object":
+		if len(s.AdditionalProperties) > 0 && s.AdditionalProperties[0] == '{' {
+			var values schema
+			if err := json.Unmarshal(s.AdditionalProperties, &values); err == nil {
+				return "map[string]" + goType(&values)
+			}
+		}
+	}
+	return "json.RawMessage"
+}
+
+// returnType is what a method returns: structs are returned as pointers.
+func returnType(s *schema) string {
+	t := goType(s)
+	if s.Ref != "" {
+		return "*" + t
+	}
+	return t
+}
+
+func zero(s *schema) string {
+	if s.Ref != "" {
+		return "nil"
+	}
+	switch goType(s) {
+	case "string":
+		return "\"\""
+	case "int", "float64":
+		return "0"
+	case "bool":
+		return "false"
+	}
+	return "nil"
+}
+
+// goName turns snake_case (or camelCase) into an exported Go name.
+func goName(s string) string {
+	var words []string
+	for _, word := range strings.Split(s, "_") {
+		// split camelCase too
+		start := 0
+		for i := 1; i < len(word); i++ {
+			if word[i] >= 'A' && word[i] <= 'Z' && word[i-1] >= 'a' && word[i-1] <= 'z' {
+				words = append(words, word[start:i])
+				start = i
+			}
+		}
+		words = append(words, word[start:])
+	}
+
+	var name string
+	for _, word := range words {
+		if word == "" {
+			continue
+		}
+		if initialism, ok := initialisms[strings.ToLower(word)]; ok {
+			name += initialism
+			continue
+		}
+		name += strings.ToUpper(word[:1]) + word[1:]
+	}
+	return name
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	// leave initialisms like ID alone
+	if len(s) > 1 && s[1] >= 'A' && s[1] <= 'Z' {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+func TestGeneratedIsCurrent(t *testing.T) {
+	want, err := generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile("../../apiclient/generated.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("apiclient/generated.go doesn't match the spec; run go generate ./apiclient")
+	}
+}
@@ -0,0 +1,297 @@
+// Package config loads wobbotfet's configuration from a config file, with environment variables taking precedence.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Sigafoos/wobbotfet/backend"
+	"github.com/Sigafoos/wobbotfet/ratelimit"
+	"github.com/spf13/viper"
+)
+
+// A Service is the configuration for a backend service.
+type Service struct {
+	// URL is the hostname of the service (no trailing slash). If it's empty the service's commands are disabled.
+	URL     string        `mapstructure:"url"`
+	Timeout time.Duration `mapstructure:"timeout"`
+	// BasicUser and BasicPass are sent as basic auth, if the service requires it.
+	BasicUser string `mapstructure:"basic_user"`
+	BasicPass string `mapstructure:"basic_pass"`
+}
+
+// An API is the configuration for the dashboard API server. If neither Token nor OAuth is set, anyone who can reach
+// it has the owner's access.
+type API struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Host    string `mapstructure:"host"`
+	Port    string `mapstructure:"port"`
+	// Token is a bearer token with the owner's access, for scripts (and Prometheus).
+	Token string `mapstructure:"token"`
+	OAuth OAuth  `mapstructure:"oauth"`
+}
+
+// An OAuth is the configuration for logging in to the dashboard API with Discord.
+type OAuth struct {
+	ClientID     string `mapstructure:"client_id"`
+	ClientSecret string `mapstructure:"client_secret"`
+	// RedirectURL is the API's `/auth/callback`, as registered with Discord.
+	RedirectURL string `mapstructure:"redirect_url"`
+}
+
+// Enabled reports whether Discord login is configured.
+func (o OAuth) Enabled() bool {
+	return o.ClientID != ""
+}
+
+// Open reports whether the API has no authentication.
+func (a API) Open() bool {
+	return a.Token == "" && !a.OAuth.Enabled()
+}
+
+// A Health is the configuration for the health check server. It's always on, and only says whether wobbotfet is
+// working, so it's safe to expose.
+type Health struct {
+	Host string `mapstructure:"host"`
+	Port string `mapstructure:"port"`
+}
+
+// An AccessLog is the configuration for the log of every command handled.
+type AccessLog struct {
+	Path string `mapstructure:"path"`
+	// MaxSize is how big the log can get, in megabytes, before it's rotated. 0 means no limit.
+	MaxSize int64 `mapstructure:"max_size"`
+	// RotateEvery is how long a log is written to before it's rotated. 0 means it isn't rotated on a schedule.
+	RotateEvery time.Duration `mapstructure:"rotate_every"`
+	// Compress gzips rotated logs.
+	Compress bool `mapstructure:"compress"`
+	// MaxBackups is how many rotated logs to keep. 0 keeps them all.
+	MaxBackups int `mapstructure:"max_backups"`
+}
+
+// An Errors is the configuration for collecting the errors wobbotfet runs into.
+type Errors struct {
+	// Digest is how often the owner is PMed what's gone wrong since the last time.
+	Digest time.Duration `mapstructure:"digest"`
+	// Recent is how many errors are kept for the dashboard API.
+	Recent int `mapstructure:"recent"`
+}
+
+// A RateLimit is the configuration for how often commands can be used. Each limit is a count and a duration (ie
+// `10/1m` for 10 a minute), or `off`. The owner and server admins aren't limited.
+type RateLimit struct {
+	// User limits each user, across every server.
+	User string `mapstructure:"user"`
+	// Guild limits everyone in a server put together.
+	Guild string `mapstructure:"guild"`
+	// Commands limits each user's use of a command, by command.
+	Commands map[string]string `mapstructure:"commands"`
+}
+
+// A Config is everything wobbotfet needs to run.
+type Config struct {
+	// Path and Environment are what the config was loaded with, so it can be loaded again.
+	Path        string `mapstructure:"-"`
+	Environment string `mapstructure:"-"`
+
+	// Token is the Discord bot token. If it isn't set directly, it's picked from Tokens.
+	Token string `mapstructure:"-"`
+	// Tokens holds a token per environment (ie `token.prod` and `token.dev`), as v1 did.
+	Tokens map[string]string `mapstructure:"token"`
+	// Owner is the ID of the user to PM when the bot goes up/down.
+	Owner string `mapstructure:"owner"`
+	// Version is shown as the bot's status.
+	Version string `mapstructure:"version"`
+	// GuildConfig is the path to save per-guild configuration to.
+	GuildConfig string `mapstructure:"guild_config"`
+	// DisabledCommands are turned off everywhere, ie while a service is misbehaving. `config`, `help` and `admin`
+	// can't be turned off. WOB_DISABLED_COMMANDS is comma separated.
+	DisabledCommands []string `mapstructure:"disabled_commands"`
+	// SlashCommands registers the commands as Discord slash commands.
+	SlashCommands bool `mapstructure:"slash_commands"`
+
+	AccessLog AccessLog `mapstructure:"access_log"`
+	Errors    Errors    `mapstructure:"errors"`
+	RateLimit RateLimit `mapstructure:"rate_limit"`
+
+	API    API     `mapstructure:"api"`
+	Health Health  `mapstructure:"health"`
+	Rank   Service `mapstructure:"rank"`
+	Want   Service `mapstructure:"want"`
+	PVP    Service `mapstructure:"pvp"`
+}
+
+// env maps config keys to the environment variables that override them.
+var env = map[string]string{
+	"owner":             "DISCORD_OWNER",
+	"version":           "VERSION",
+	"guild_config":      "GUILD_CONFIG",
+	"disabled_commands": "WOB_DISABLED_COMMANDS",
+	"slash_commands":    "WOB_SLASH_COMMANDS",
+	"api.enabled":       "WOB_API",
+	"api.host":          "WOB_HOST",
+	"api.port":          "WOB_PORT",
+	"api.token":         "WOB_API_TOKEN",
+	"health.host":       "WOB_HEALTH_HOST",
+	"health.port":       "WOB_HEALTH_PORT",
+	"rank.url":          "RANK_URL",
+	"rank.timeout":      "RANK_TIMEOUT",
+	"want.url":          "WANT_URL",
+	"want.timeout":      "WANT_TIMEOUT",
+	"want.basic_user":   "WANT_BASICUSER",
+	"want.basic_pass":   "WANT_BASICPASS",
+	"pvp.url":           "PVP_URL",
+	"pvp.timeout":       "PVP_TIMEOUT",
+
+	"api.oauth.client_id":     "WOB_OAUTH_CLIENT_ID",
+	"api.oauth.client_secret": "WOB_OAUTH_CLIENT_SECRET",
+	"api.oauth.redirect_url":  "WOB_OAUTH_REDIRECT_URL",
+
+	"access_log.path":         "ACCESS_LOG",
+	"access_log.max_size":     "ACCESS_LOG_MAX_SIZE",
+	"access_log.rotate_every": "ACCESS_LOG_ROTATE_EVERY",
+	"access_log.compress":     "ACCESS_LOG_COMPRESS",
+	"access_log.max_backups":  "ACCESS_LOG_MAX_BACKUPS",
+
+	"errors.digest": "WOB_ERROR_DIGEST",
+	"errors.recent": "WOB_ERROR_RECENT",
+
+	"rate_limit.user":  "WOB_RATE_LIMIT_USER",
+	"rate_limit.guild": "WOB_RATE_LIMIT_GUILD",
+}
+
+// Reload loads the config again from the same place.
+func (c *Config) Reload() (*Config, error) {
+	return Load(c.Path, c.Environment)
+}
+
+// Load reads the config file at path (or `config.yml`, `config.toml`, etc in the working directory if path is
+// empty) and applies any environment variables. It's fine for there to be no config file if everything is in the
+// environment. environment picks which of Tokens to use if DISCORD_TOKEN isn't set.
+func Load(path, environment string) (*Config, error) {
+	v := viper.New()
+	v.SetDefault("guild_config", "guilds.json")
+	v.SetDefault("slash_commands", true)
+	v.SetDefault("access_log.path", "access.log")
+	v.SetDefault("access_log.max_size", 100)
+	v.SetDefault("access_log.rotate_every", 24*time.Hour)
+	v.SetDefault("access_log.compress", true)
+	v.SetDefault("access_log.max_backups", 30)
+	v.SetDefault("errors.digest", 10*time.Minute)
+	v.SetDefault("errors.recent", 100)
+	v.SetDefault("rate_limit.user", "10/1m")
+	v.SetDefault("rate_limit.guild", "60/1m")
+	v.SetDefault("api.host", "0.0.0.0")
+	v.SetDefault("api.port", "8081")
+	v.SetDefault("health.host", "0.0.0.0")
+	v.SetDefault("health.port", "8082")
+	v.SetDefault("rank.timeout", backend.DefaultTimeout)
+	v.SetDefault("want.timeout", backend.DefaultTimeout)
+	v.SetDefault("pvp.timeout", backend.DefaultTimeout)
+
+	for key, name := range env {
+		if err := v.BindEnv(key, name); err != nil {
+			return nil, err
+		}
+	}
+
+	if path != "" {
+		v.SetConfigFile(path)
+	} else {
+		v.SetConfigName("config")
+		v.AddConfigPath(".")
+	}
+	if err := v.ReadInConfig(); err != nil {
+		// a missing file is only a problem if they asked for a specific one
+		if _, notFound := err.(viper.ConfigFileNotFoundError); !notFound || path != "" {
+			return nil, fmt.Errorf("error reading config file: %w", err)
+		}
+	}
+
+	c := &Config{}
+	if err := v.Unmarshal(c); err != nil {
+		return nil, fmt.Errorf("error decoding config: %w", err)
+	}
+	c.Path = path
+	c.Environment = environment
+
+	if err := v.BindEnv("discord_token", "DISCORD_TOKEN"); err != nil {
+		return nil, err
+	}
+	c.Token = v.GetString("discord_token")
+	if c.Token == "" {
+		c.Token = c.Tokens[environment]
+	}
+
+	return c, c.Validate(environment)
+}
+
+// Validate returns an error if wobbotfet can't run with the config.
+func (c *Config) Validate(environment string) error {
+	if c.Token == "" {
+		return fmt.Errorf("no DISCORD_TOKEN in the environment and no `token.%s` in the config file", environment)
+	}
+	if c.AccessLog.Path == "" {
+		return errors.New("access_log.path (ACCESS_LOG) can't be empty")
+	}
+	if c.Errors.Digest <= 0 {
+		return errors.New("errors.digest (WOB_ERROR_DIGEST) has to be positive")
+	}
+	if c.Errors.Recent < 0 {
+		return errors.New("errors.recent (WOB_ERROR_RECENT) can't be negative")
+	}
+	if _, err := ratelimit.Parse(c.RateLimit.User); err != nil {
+		return fmt.Errorf("rate_limit.user (WOB_RATE_LIMIT_USER): %w", err)
+	}
+	if _, err := ratelimit.Parse(c.RateLimit.Guild); err != nil {
+		return fmt.Errorf("rate_limit.guild (WOB_RATE_LIMIT_GUILD): %w", err)
+	}
+	for command, limit := range c.RateLimit.Commands {
+		if _, err := ratelimit.Parse(limit); err != nil {
+			return fmt.Errorf("rate_limit.commands.%s: %w", command, err)
+		}
+	}
+	if c.API.Port == "" {
+		return errors.New("api.port (WOB_PORT) can't be empty")
+	}
+	if c.API.OAuth.Enabled() && (c.API.OAuth.ClientSecret == "" || c.API.OAuth.RedirectURL == "") {
+		return errors.New("api.oauth needs a client_secret (WOB_OAUTH_CLIENT_SECRET) and redirect_url (WOB_OAUTH_REDIRECT_URL) as well as a client_id")
+	}
+	if c.Health.Port == "" {
+		return errors.New("health.port (WOB_HEALTH_PORT) can't be empty")
+	}
+	for name, s := range map[string]Service{"rank": c.Rank, "want": c.Want, "pvp": c.PVP} {
+		if s.Timeout < 0 {
+			return fmt.Errorf("%s.timeout can't be negative", name)
+		}
+	}
+	return nil
+}
+
+// Report describes what's disabled by the config (and why), one line per thing. It's empty if everything is enabled.
+func (c *Config) Report() []string {
+	var report []string
+	if c.Rank.URL == "" {
+		report = append(report, "rank commands (rank, vrank, betterthan) are disabled: no rank.url (RANK_URL)")
+	}
+	if c.Want.URL == "" {
+		report = append(report, "want commands (want, unwant, wants, search) are disabled: no want.url (WANT_URL)")
+	}
+	if c.PVP.URL == "" {
+		report = append(report, "the pvp command is disabled: no pvp.url (PVP_URL)")
+	}
+	if c.Owner == "" {
+		report = append(report, "owner PMs and admin commands are disabled: no owner (DISCORD_OWNER)")
+	}
+	if !c.API.Enabled {
+		report = append(report, "the dashboard API is disabled: api.enabled (WOB_API) isn't set")
+	} else if c.API.Open() {
+		report = append(report, "the dashboard API has no authentication: set api.token (WOB_API_TOKEN) or api.oauth")
+	}
+	if len(c.DisabledCommands) > 0 {
+		report = append(report, "turned off by disabled_commands (WOB_DISABLED_COMMANDS): "+strings.Join(c.DisabledCommands, ", "))
+	}
+	return report
+}
@@ -0,0 +1,16 @@
+// Package dashboard is the admin dashboard: a single page that only uses the dashboard API, served from the same
+// server.
+package dashboard
+
+import (
+	"net/http"
+)
+
+// Index serves the dashboard.
+func Index(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	// everything's inline, and it only talks to the API it's served with
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; script-src 'unsafe-inline'; style-src 'unsafe-inline'; img-src https://cdn.discordapp.com; connect-src 'self'; form-action 'self'; frame-ancestors 'none'")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Write([]byte(index))
+}
@@ -0,0 +1,358 @@
+package dashboard
+
+// index is the whole dashboard. It builds the page with textContent rather than innerHTML, since role names, IGNs
+// and the like come from users. (It's a raw string, so no backticks in here.)
+const index = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>wobbotfet</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif; margin: 0; color: #222; background: #f4f4f8; }
+header { background: #2c4f8c; color: #fff; padding: 0.75em 1.5em; display: flex; justify-content: space-between; align-items: center; }
+header h1 { margin: 0; font-size: 1.4em; }
+header a, header button { color: #fff; }
+main { display: flex; gap: 1.5em; padding: 1.5em; align-items: flex-start; }
+nav { min-width: 16em; }
+nav ul { list-style: none; padding: 0; margin: 0; }
+nav li { padding: 0.5em; border-radius: 4px; cursor: pointer; display: flex; align-items: center; gap: 0.5em; }
+nav li:hover, nav li.selected { background: #dde4f2; }
+nav img { width: 24px; height: 24px; border-radius: 50%; }
+#content { flex: 1; }
+section { background: #fff; border-radius: 6px; padding: 1em 1.25em; margin-bottom: 1.25em; box-shadow: 0 1px 2px rgba(0,0,0,0.08); }
+section h2 { margin-top: 0; font-size: 1.1em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: 0.3em 0.6em; border-bottom: 1px solid #eee; }
+.tag { display: inline-block; background: #eef; border-radius: 3px; padding: 0.1em 0.4em; margin: 0.1em; font-size: 0.85em; }
+.muted { color: #888; }
+.error { color: #b00020; }
+#feed { max-height: 20em; overflow-y: auto; font-family: monospace; font-size: 0.9em; }
+#feed div { padding: 0.15em 0; border-bottom: 1px solid #f2f2f2; }
+.swatch { display: inline-block; width: 0.8em; height: 0.8em; border-radius: 50%; margin-right: 0.4em; vertical-align: middle; }
+#login { max-width: 30em; margin: 3em auto; }
+#login input { width: 100%; box-sizing: border-box; padding: 0.4em; margin: 0.5em 0; }
+</style>
+</head>
+<body>
+<header>
+	<h1>wobbotfet</h1>
+	<div id="who"></div>
+</header>
+<div id="app"></div>
+<script>
+"use strict";
+
+// the API token, if that's how we logged in. sessions use a cookie instead.
+var token = sessionStorage.getItem("wob_token");
+
+function el(tag, attrs) {
+	var e = document.createElement(tag);
+	for (var k in attrs || {}) {
+		if (k === "text") {
+			e.textContent = attrs[k];
+		} else if (k === "onclick") {
+			e.onclick = attrs[k];
+		} else {
+			e.setAttribute(k, attrs[k]);
+		}
+	}
+	for (var i = 2; i < arguments.length; i++) {
+		var child = arguments[i];
+		if (child === null || child === undefined) {
+			continue;
+		}
+		e.appendChild(typeof child === "string" ? document.createTextNode(child) : child);
+	}
+	return e;
+}
+
+function clear(e) {
+	while (e.firstChild) {
+		e.removeChild(e.firstChild);
+	}
+	return e;
+}
+
+function api(path) {
+	var headers = {};
+	if (token) {
+		headers["Authorization"] = "Bearer " + token;
+	}
+	return fetch(path, {headers: headers, credentials: "same-origin"}).then(function(resp) {
+		if (!resp.ok) {
+			var err = new Error(resp.status + " " + resp.statusText);
+			err.status = resp.status;
+			throw err;
+		}
+		return resp.json();
+	});
+}
+
+// the live feed that's open, so it can be closed when we look at something else
+var feed = null;
+
+// stream reads the server-sent events at path, calling onEvent with each one. It uses fetch rather than EventSource so
+// the API token can be sent.
+function stream(path, onEvent, onError) {
+	if (feed) {
+		feed.abort();
+	}
+	feed = new AbortController();
+	var headers = {};
+	if (token) {
+		headers["Authorization"] = "Bearer " + token;
+	}
+	fetch(path, {headers: headers, credentials: "same-origin", signal: feed.signal}).then(function(resp) {
+		if (!resp.ok) {
+			throw new Error(resp.status + " " + resp.statusText);
+		}
+		var reader = resp.body.getReader();
+		var decoder = new TextDecoder();
+		var buffer = "";
+		function read() {
+			return reader.read().then(function(chunk) {
+				if (chunk.done) {
+					return;
+				}
+				buffer += decoder.decode(chunk.value, {stream: true});
+				var messages = buffer.split("\n\n");
+				buffer = messages.pop();
+				messages.forEach(function(message) {
+					message.split("\n").forEach(function(line) {
+						if (line.indexOf("data: ") === 0) {
+							onEvent(JSON.parse(line.slice(6)));
+						}
+					});
+				});
+				return read();
+			});
+		}
+		return read();
+	}).catch(function(err) {
+		if (err.name !== "AbortError") {
+			onError(err);
+		}
+	});
+}
+
+function describeEvent(e) {
+	var d = e.data;
+	switch (e.type) {
+	case "command":
+		return d.command + " " + (d.args || []).join(" ") + " by " + d.user + ": " + d.outcome + " (" + d.latency_ms.toFixed(0) + "ms)";
+	case "backend_error":
+		return d.service + " " + d.method + " " + d.path + ": " + d.status + " after " + d.attempts + " attempts";
+	case "panic":
+		return "panic in " + d.command + " by " + d.user + ": " + d.error;
+	case "conversation":
+		return "PM " + d.channel + " " + d.state;
+	}
+	return JSON.stringify(d);
+}
+
+// liveFeed adds a section showing events from path as they happen, newest first.
+function liveFeed(parent, path) {
+	var list = el("div", {id: "feed"}, el("p", {"class": "muted", text: "waiting for something to happen..."}));
+	parent.appendChild(el("section", {}, el("h2", {text: "Live"}), list));
+	stream(path, function(e) {
+		if (list.firstChild && list.firstChild.tagName === "P") {
+			clear(list);
+		}
+		var line = el("div", {"class": e.type === "command" ? "" : "error"}, new Date(e.time).toLocaleTimeString() + " " + describeEvent(e));
+		list.insertBefore(line, list.firstChild);
+		while (list.children.length > 200) {
+			list.removeChild(list.lastChild);
+		}
+	}, function(err) {
+		clear(list).appendChild(el("p", {"class": "error", text: err.message}));
+	});
+}
+
+function table(columns, rows) {
+	if (!rows.length) {
+		return el("p", {"class": "muted", text: "nothing here"});
+	}
+	var head = el("tr");
+	columns.forEach(function(c) {
+		head.appendChild(el("th", {text: c}));
+	});
+	var t = el("table", {}, el("thead", {}, head));
+	var body = el("tbody");
+	rows.forEach(function(row) {
+		var tr = el("tr");
+		row.forEach(function(cell) {
+			tr.appendChild(typeof cell === "object" && cell !== null ? el("td", {}, cell) : el("td", {text: String(cell)}));
+		});
+		body.appendChild(tr);
+	});
+	t.appendChild(body);
+	return t;
+}
+
+// section adds a titled section to parent and fills it in with render once load finishes.
+function section(parent, title, load, render) {
+	var s = el("section", {}, el("h2", {text: title}), el("p", {"class": "muted", text: "loading..."}));
+	parent.appendChild(s);
+	load().then(function(data) {
+		s.removeChild(s.lastChild);
+		s.appendChild(render(data));
+	}).catch(function(err) {
+		s.removeChild(s.lastChild);
+		var message = err.status === 404 ? "not available (is the service configured?)" : err.message;
+		s.appendChild(el("p", {"class": err.status === 404 ? "muted" : "error", text: message}));
+	});
+}
+
+function renderUsage(usage) {
+	var names = Object.keys(usage.commands).sort(function(a, b) {
+		return usage.commands[b].count - usage.commands[a].count;
+	});
+	var rows = names.map(function(name) {
+		var c = usage.commands[name];
+		var outcomes = el("span");
+		Object.keys(c.outcomes).forEach(function(o) {
+			outcomes.appendChild(el("span", {"class": "tag", text: o + ": " + c.outcomes[o]}));
+		});
+		return [name, c.count, c.users, c.avg_latency_ms.toFixed(0) + "ms", outcomes];
+	});
+	return el("div", {},
+		el("p", {text: usage.total + " commands from " + usage.users + " users since " + new Date(usage.since).toLocaleString()}),
+		table(["command", "count", "users", "avg latency", "outcomes"], rows));
+}
+
+function colour(c) {
+	return "#" + ("000000" + c.toString(16)).slice(-6);
+}
+
+function showServer(server) {
+	var content = clear(document.getElementById("content"));
+	var base = "/servers/" + encodeURIComponent(server.id);
+	content.appendChild(el("h2", {text: server.name}));
+
+	var perms = el("section", {}, el("h2", {text: "Permissions"}));
+	(server.parsed_permissions || []).forEach(function(p) {
+		perms.appendChild(el("span", {"class": "tag", text: p}));
+	});
+	content.appendChild(perms);
+	liveFeed(content, "/events?guild=" + encodeURIComponent(server.id));
+
+	section(content, "Configuration", function() { return api(base + "/config"); }, function(c) {
+		return table(["setting", "value"], Object.keys(c).map(function(k) {
+			var v = c[k];
+			return [k, Array.isArray(v) ? v.join(", ") : String(v)];
+		}));
+	});
+	section(content, "Recent activity (last 24 hours)", function() { return api(base + "/usage"); }, renderUsage);
+	section(content, "Want roles", function() { return api(base + "/wants"); }, function(wants) {
+		return table(["pokemon", "role", "members"], wants.map(function(w) {
+			return [w.pokemon, w.name, w.members];
+		}));
+	});
+	section(content, "PVP roster", function() {
+		return Promise.all([api(base + "/friends"), api(base + "/battling")]);
+	}, function(data) {
+		var graph = data[0], battling = {}, friends = {};
+		data[1].forEach(function(id) { battling[id] = true; });
+		graph.friendships.forEach(function(f) {
+			friends[f.user] = (friends[f.user] || 0) + 1;
+			friends[f.friend] = (friends[f.friend] || 0) + 1;
+		});
+		return table(["player", "IGN", "ultra friends", "egg ultra", "battling"], graph.players.map(function(p) {
+			return [p.username, p.ign, friends[p.id] || 0, p.egg_ultra ? "yes" : "", battling[p.id] ? "yes" : ""];
+		}));
+	});
+	section(content, "Roles", function() { return api(base + "/roles"); }, function(roles) {
+		roles.sort(function(a, b) { return b.position - a.position; });
+		return table(["role", "mentionable", "managed"], roles.map(function(r) {
+			return [el("span", {}, el("span", {"class": "swatch", style: "background: " + colour(r.color)}), r.name), r.mentionable ? "yes" : "", r.managed ? "yes" : ""];
+		}));
+	});
+}
+
+function showOwner() {
+	var content = clear(document.getElementById("content"));
+	content.appendChild(el("h2", {text: "Everywhere"}));
+	liveFeed(content, "/events");
+	section(content, "Active conversations", function() { return api("/pms"); }, function(pms) {
+		return table(["PM channel"], pms.map(function(pm) { return [pm]; }));
+	});
+	section(content, "Recent activity (last 24 hours)", function() { return api("/usage"); }, renderUsage);
+	section(content, "Recent errors", function() { return api("/errors"); }, function(errors) {
+		return table(["time", "kind", "command", "server", "message", "signature"], errors.map(function(e) {
+			return [new Date(e.time).toLocaleString(), e.kind, e.command || "", e.guild || "", e.message, e.signature];
+		}));
+	});
+}
+
+function showDashboard(me) {
+	var who = clear(document.getElementById("who"));
+	who.appendChild(document.createTextNode(me.owner ? "owner " : "logged in "));
+	who.appendChild(el("button", {text: "log out", onclick: function() {
+		sessionStorage.removeItem("wob_token");
+		var headers = token ? {"Authorization": "Bearer " + token} : {};
+		fetch("/auth/logout", {method: "POST", headers: headers, credentials: "same-origin"}).then(function() {
+			location.reload();
+		});
+	}}));
+
+	var list = el("ul");
+	var app = clear(document.getElementById("app"));
+	app.appendChild(el("main", {}, el("nav", {}, list), el("div", {id: "content"})));
+
+	function select(li, show) {
+		Array.prototype.forEach.call(list.children, function(c) { c.className = ""; });
+		li.className = "selected";
+		show();
+	}
+	if (me.owner) {
+		var everywhere = el("li", {text: "Everywhere"});
+		everywhere.onclick = function() { select(everywhere, showOwner); };
+		list.appendChild(everywhere);
+	}
+
+	api("/servers").then(function(servers) {
+		servers.forEach(function(s) {
+			var icon = s.icon ? el("img", {src: "https://cdn.discordapp.com/icons/" + s.id + "/" + s.icon + ".png?size=32", alt: ""}) : null;
+			var li = el("li", {}, icon, s.name);
+			li.onclick = function() { select(li, function() { showServer(s); }); };
+			list.appendChild(li);
+		});
+		if (list.firstChild) {
+			list.firstChild.onclick();
+		} else {
+			document.getElementById("content").appendChild(el("p", {"class": "muted", text: "there aren't any servers you can manage"}));
+		}
+	}).catch(function(err) {
+		document.getElementById("content").appendChild(el("p", {"class": "error", text: err.message}));
+	});
+}
+
+function showLogin() {
+	var input = el("input", {type: "password", placeholder: "API token"});
+	var form = el("form", {}, input, el("button", {type: "submit", text: "use token"}));
+	form.onsubmit = function(e) {
+		e.preventDefault();
+		sessionStorage.setItem("wob_token", input.value);
+		location.reload();
+	};
+	clear(document.getElementById("app")).appendChild(el("section", {id: "login"},
+		el("h2", {text: "Log in"}),
+		el("p", {}, el("a", {href: "/auth/login", text: "Log in with Discord"})),
+		el("p", {"class": "muted", text: "or, if you have the API token:"}),
+		form));
+}
+
+api("/auth/me").then(showDashboard).catch(function(err) {
+	if (err.status === 401) {
+		sessionStorage.removeItem("wob_token");
+		token = null;
+		showLogin();
+		return;
+	}
+	clear(document.getElementById("app")).appendChild(el("p", {"class": "error", text: err.message}));
+});
+</script>
+</body>
+</html>
+`
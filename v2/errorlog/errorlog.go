@@ -0,0 +1,211 @@
+// Package errorlog collects the errors wobbotfet runs into, groups the ones that are the same problem, and sends
+// digests of them, so something breaking in a loop doesn't mean a message per failure.
+package errorlog
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kinds of Error.
+const (
+	// KindPanic is a recovered panic.
+	KindPanic = "panic"
+	// KindError is an error that was handled, but shouldn't have happened.
+	KindError = "error"
+)
+
+// An Error is something that went wrong, and what was being done when it did.
+type Error struct {
+	Kind string    `json:"kind"`
+	Time time.Time `json:"time"`
+	// Signature is the same for errors that are the same problem. See PanicSignature and ErrorSignature.
+	Signature string `json:"signature"`
+	Message   string `json:"message"`
+	// Stack is only set for panics.
+	Stack   string `json:"stack,omitempty"`
+	Command string `json:"command,omitempty"`
+	// Guild is empty for errors in a PM, or that didn't happen while handling a command.
+	Guild   string `json:"guild,omitempty"`
+	Channel string `json:"channel,omitempty"`
+	User    string `json:"user,omitempty"`
+}
+
+// A Group is every time an error with the same signature happened since the last digest.
+type Group struct {
+	// First is the first time it happened.
+	First Error
+	Count int
+	Last  time.Time
+	// Guilds is how many guilds it happened in.
+	Guilds int
+	// New means it hasn't happened before since starting.
+	New bool
+
+	guilds map[string]bool
+}
+
+// A Digester is sent each digest: the groups in the order they first happened.
+type Digester func([]*Group)
+
+// An Aggregator keeps the most recent errors, and sends a digest of what's happened every so often.
+type Aggregator struct {
+	send Digester
+
+	mu sync.Mutex
+	// recent is a ring of the most recent errors. next is where the next one goes.
+	recent []Error
+	next   int
+	full   bool
+	// pending are the errors since the last digest, by signature.
+	pending map[string]*Group
+	order   []string
+	// seen is every signature since starting.
+	seen map[string]bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// New returns an Aggregator that keeps the last size errors and sends a digest to send every interval, if anything's
+// happened. Close it to send what's left.
+func New(size int, interval time.Duration, send Digester) *Aggregator {
+	a := &Aggregator{
+		send:    send,
+		recent:  make([]Error, size),
+		pending: make(map[string]*Group),
+		seen:    make(map[string]bool),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go a.run(interval)
+	return a
+}
+
+func (a *Aggregator) run(interval time.Duration) {
+	defer close(a.done)
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			a.Flush()
+		case <-a.stop:
+			a.Flush()
+			return
+		}
+	}
+}
+
+// Report records an error. If its Time isn't set it's now.
+func (a *Aggregator) Report(e Error) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(a.recent) > 0 {
+		a.recent[a.next] = e
+		a.next = (a.next + 1) % len(a.recent)
+		if a.next == 0 {
+			a.full = true
+		}
+	}
+
+	g, ok := a.pending[e.Signature]
+	if !ok {
+		g = &Group{
+			First:  e,
+			New:    !a.seen[e.Signature],
+			guilds: make(map[string]bool),
+		}
+		a.pending[e.Signature] = g
+		a.order = append(a.order, e.Signature)
+		a.seen[e.Signature] = true
+	}
+	g.Count++
+	g.Last = e.Time
+	if e.Guild != "" && !g.guilds[e.Guild] {
+		g.guilds[e.Guild] = true
+		g.Guilds++
+	}
+}
+
+// Recent returns the most recent errors, newest first.
+func (a *Aggregator) Recent() []Error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	n := a.next
+	if a.full {
+		n = len(a.recent)
+	}
+	errs := make([]Error, 0, n)
+	for i := 1; i <= n; i++ {
+		errs = append(errs, a.recent[(a.next-i+len(a.recent))%len(a.recent)])
+	}
+	return errs
+}
+
+// Flush sends a digest of the errors since the last one now, if there are any.
+func (a *Aggregator) Flush() {
+	a.mu.Lock()
+	groups := make([]*Group, 0, len(a.order))
+	for _, sig := range a.order {
+		groups = append(groups, a.pending[sig])
+	}
+	a.pending = make(map[string]*Group)
+	a.order = nil
+	a.mu.Unlock()
+
+	if len(groups) > 0 {
+		a.send(groups)
+	}
+}
+
+// Close stops sending digests, after sending what's left.
+func (a *Aggregator) Close() {
+	close(a.stop)
+	<-a.done
+}
+
+var (
+	// numbers are IDs, sizes, addresses and the like, which differ between errors that are the same problem
+	numberre = regexp.MustCompile(`\d+`)
+	// a frame's offset and arguments differ between builds and calls
+	offsetre = regexp.MustCompile(` \+0x[0-9a-f]+$`)
+	argsre   = regexp.MustCompile(`\([^()]*\)$`)
+)
+
+// PanicSignature identifies a panic by what it was and where it happened: the panic value (ignoring numbers) and
+// the stack (ignoring the goroutine and the arguments).
+func PanicSignature(value interface{}, stack []byte) string {
+	lines := strings.Split(string(stack), "\n")
+	frames := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if strings.HasPrefix(line, "goroutine ") {
+			continue
+		}
+		line = offsetre.ReplaceAllString(strings.TrimSpace(line), "")
+		frames = append(frames, argsre.ReplaceAllString(line, ""))
+	}
+	return signature(numberre.ReplaceAllString(fmt.Sprint(value), "N"), strings.Join(frames, "\n"))
+}
+
+// ErrorSignature identifies a handled error by where it was reported (ie `file.go:123`) and a description that's the
+// same each time it happens, like the format string it was logged with. Numbers in the description are ignored.
+func ErrorSignature(location, message string) string {
+	return signature(location, numberre.ReplaceAllString(message, "N"))
+}
+
+func signature(parts ...string) string {
+	sum := sha1.Sum([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:6])
+}
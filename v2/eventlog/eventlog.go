@@ -0,0 +1,86 @@
+// Package eventlog writes an event for every command wobbotfet handles, as JSON lines.
+package eventlog
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Outcomes of a command.
+const (
+	// OutcomeOK means the command ran and the response was sent.
+	OutcomeOK = "ok"
+	// OutcomeUnknown means there's no such command.
+	OutcomeUnknown = "unknown_command"
+	// OutcomeDisabled means the command is turned off in the guild, or everywhere.
+	OutcomeDisabled = "disabled"
+	// OutcomeBadArgs means the command's arguments couldn't be parsed, or there's no such subcommand.
+	OutcomeBadArgs = "bad_args"
+	// OutcomeForbidden means the user can't use the command (or can't use it there), ie it's for server admins.
+	OutcomeForbidden = "forbidden"
+	// OutcomeRateLimited means the user (or their guild) has used too many commands, and has to wait.
+	OutcomeRateLimited = "rate_limited"
+	// OutcomeBackendError means a service call failed.
+	OutcomeBackendError = "backend_error"
+	// OutcomePanic means the handler panicked.
+	OutcomePanic = "panic"
+	// OutcomeSendError means the response couldn't be sent to Discord.
+	OutcomeSendError = "send_error"
+)
+
+// CommandPMReply is the command recorded for a reply to a question wobbotfet asked in a PM. Its args aren't logged.
+const CommandPMReply = "pm_reply"
+
+// An Event is a handled command. The JSON field names are the log's schema, so don't change them.
+type Event struct {
+	Time    time.Time `json:"time"`
+	Guild   string    `json:"guild"`
+	Channel string    `json:"channel"`
+	User    string    `json:"user"`
+	Command string    `json:"command"`
+	Args    []string  `json:"args"`
+	// Latency is how long it took to respond, in milliseconds.
+	Latency float64 `json:"latency_ms"`
+	Outcome string  `json:"outcome"`
+	// BackendStatus has a `service:status` entry for each service call made, ie `rank:200` or `want:timeout`.
+	BackendStatus []string `json:"backend_status"`
+}
+
+// A Logger writes events to w, one JSON object per line.
+type Logger struct {
+	sync.Mutex
+	w   io.Writer
+	enc *json.Encoder
+}
+
+// New returns a Logger writing to w.
+func New(w io.Writer) *Logger {
+	return &Logger{
+		w:   w,
+		enc: json.NewEncoder(w),
+	}
+}
+
+// Log writes an event. Nil slices are written as empty arrays so every line has the same shape.
+func (l *Logger) Log(e *Event) error {
+	if e.Args == nil {
+		e.Args = []string{}
+	}
+	if e.BackendStatus == nil {
+		e.BackendStatus = []string{}
+	}
+
+	l.Lock()
+	defer l.Unlock()
+	return l.enc.Encode(e)
+}
+
+// Close closes the underlying writer, if it can be closed.
+func (l *Logger) Close() error {
+	if c, ok := l.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
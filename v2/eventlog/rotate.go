@@ -0,0 +1,200 @@
+package eventlog
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTime is how rotated files are timestamped, ie access-20190801T120000.000.log.
+const backupTime = "20060102T150405.000"
+
+// A RotatingFile is a file that's moved aside and replaced by a new one when it gets too big or too old.
+type RotatingFile struct {
+	sync.Mutex
+	path string
+	opts RotateOptions
+
+	file   *os.File
+	size   int64
+	opened time.Time
+
+	// cleanup is held while old files are compressed and pruned, so each rotation's cleanup waits on the last one's.
+	cleanup sync.Mutex
+}
+
+// RotateOptions control when a RotatingFile rotates and what happens to the old files.
+type RotateOptions struct {
+	// MaxSize is how big (in bytes) the file can get before it's rotated. 0 means no limit.
+	MaxSize int64
+	// MaxAge is how long a file is written to before it's rotated. 0 means no limit.
+	MaxAge time.Duration
+	// Compress gzips rotated files.
+	Compress bool
+	// MaxBackups is how many rotated files to keep. 0 keeps them all.
+	MaxBackups int
+}
+
+// OpenRotating opens (or creates) the file at path for appending.
+func OpenRotating(path string, opts RotateOptions) (*RotatingFile, error) {
+	f := &RotatingFile{
+		path: path,
+		opts: opts,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write writes p to the file, rotating first if p would take it over MaxSize or it's older than MaxAge.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	tooBig := f.opts.MaxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.opts.MaxSize
+	tooOld := f.opts.MaxAge > 0 && time.Since(f.opened) > f.opts.MaxAge
+	if tooBig || tooOld {
+		if err := f.rotate(); err != nil {
+			// better to keep writing to a big file than to lose the event
+			log.Printf("error rotating %s: %s", f.path, err)
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Close closes the file.
+func (f *RotatingFile) Close() error {
+	f.Lock()
+	defer f.Unlock()
+	return f.file.Close()
+}
+
+// open must be called with the lock held (or before anyone else has the file).
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.opened = time.Now()
+	// an existing file is as old as its last write, which is close enough
+	if info.Size() > 0 {
+		f.opened = info.ModTime()
+	}
+	return nil
+}
+
+// rotate must be called with the lock held. If it fails the file's left as it was, so it can still be written to.
+func (f *RotatingFile) rotate() error {
+	ext := filepath.Ext(f.path)
+	backup := fmt.Sprintf("%s-%s%s", strings.TrimSuffix(f.path, ext), time.Now().Format(backupTime), ext)
+	// it's moved aside while it's still open, so it can be moved back if there's no new file to switch to
+	if err := os.Rename(f.path, backup); err != nil {
+		return err
+	}
+	old := f.file
+	if err := f.open(); err != nil {
+		if renameErr := os.Rename(backup, f.path); renameErr != nil {
+			log.Printf("error moving %s back to %s: %s", backup, f.path, renameErr)
+		}
+		return err
+	}
+	// everything's been written, and the new file's open, so this isn't worth not rotating over
+	if err := old.Close(); err != nil {
+		log.Printf("error closing %s: %s", backup, err)
+	}
+
+	go func() {
+		f.cleanup.Lock()
+		defer f.cleanup.Unlock()
+		if f.opts.Compress {
+			if err := compress(backup); err != nil {
+				log.Printf("error compressing %s: %s", backup, err)
+			}
+		}
+		f.prune()
+	}()
+	return nil
+}
+
+// prune deletes the oldest rotated files beyond MaxBackups.
+func (f *RotatingFile) prune() {
+	if f.opts.MaxBackups <= 0 {
+		return
+	}
+
+	ext := filepath.Ext(f.path)
+	backups, err := filepath.Glob(strings.TrimSuffix(f.path, ext) + "-*" + ext + "*")
+	if err != nil {
+		log.Printf("error finding old logs: %s", err)
+		return
+	}
+	// a file that didn't finish being compressed is there with and without .gz, but it's one backup
+	seen := make(map[string]bool)
+	var unique []string
+	for _, backup := range backups {
+		backup = strings.TrimSuffix(backup, ".gz")
+		if !seen[backup] {
+			seen[backup] = true
+			unique = append(unique, backup)
+		}
+	}
+	// the timestamps sort in the order they were written
+	sort.Strings(unique)
+	for len(unique) > f.opts.MaxBackups {
+		for _, path := range []string{unique[0], unique[0] + ".gz"} {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				log.Printf("error removing old log: %s", err)
+			}
+		}
+		unique = unique[1:]
+	}
+}
+
+func compress(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(path+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		gz.Close()
+		out.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		out.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+	return os.Remove(path)
+}
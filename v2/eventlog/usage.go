@@ -0,0 +1,144 @@
+package eventlog
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Usage is a summary of the commands handled over a period.
+type Usage struct {
+	Since time.Time `json:"since"`
+	Until time.Time `json:"until"`
+	Total int       `json:"total"`
+	// Users is how many different users ran commands.
+	Users    int                      `json:"users"`
+	Commands map[string]*CommandUsage `json:"commands"`
+}
+
+// CommandUsage is how a command was used. Commands that don't exist are all counted as `unknown`.
+type CommandUsage struct {
+	Count int `json:"count"`
+	Users int `json:"users"`
+	// Outcomes counts each outcome.
+	Outcomes map[string]int `json:"outcomes"`
+	// AvgLatency is the average time it took to respond, in milliseconds.
+	AvgLatency float64 `json:"avg_latency_ms"`
+}
+
+// Summarize reads the log at path (including rotated logs) and summarizes the events since then. If guild isn't
+// empty, only that guild's events are counted.
+func Summarize(path string, since time.Time, guild string) (*Usage, error) {
+	u := &Usage{
+		Since:    since,
+		Until:    time.Now(),
+		Commands: make(map[string]*CommandUsage),
+	}
+	users := make(map[string]bool)
+	commandUsers := make(map[string]map[string]bool)
+
+	err := Read(path, since, func(e *Event) {
+		if e.Time.Before(since) || (guild != "" && e.Guild != guild) {
+			return
+		}
+
+		command := e.Command
+		if e.Outcome == OutcomeUnknown {
+			command = "unknown"
+		}
+		c, ok := u.Commands[command]
+		if !ok {
+			c = &CommandUsage{Outcomes: make(map[string]int)}
+			u.Commands[command] = c
+			commandUsers[command] = make(map[string]bool)
+		}
+
+		// a running average, so we don't have to keep every latency around
+		c.Count++
+		c.AvgLatency += (e.Latency - c.AvgLatency) / float64(c.Count)
+		c.Outcomes[e.Outcome]++
+		commandUsers[command][e.User] = true
+
+		u.Total++
+		users[e.User] = true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	u.Users = len(users)
+	for command, c := range u.Commands {
+		c.Users = len(commandUsers[command])
+	}
+	return u, nil
+}
+
+// Read calls f for every event in the log at path and the logs rotated out of it since then, oldest first. Events in
+// those logs from before since aren't filtered out. Lines that can't be parsed (like one being written) are skipped.
+func Read(path string, since time.Time, f func(*Event)) error {
+	ext := filepath.Ext(path)
+	prefix := strings.TrimSuffix(path, ext) + "-"
+	backups, err := filepath.Glob(prefix + "*" + ext + "*")
+	if err != nil {
+		return err
+	}
+
+	// Glob sorts them, and the timestamps sort in the order they were written
+	seen := make(map[string]bool)
+	var files []string
+	for _, backup := range backups {
+		name := strings.TrimSuffix(backup, ".gz")
+		// rotated logs are named for when they were rotated, so anything from before since is too old
+		rotated, err := time.ParseInLocation(backupTime, strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext), time.Local)
+		if err != nil || rotated.Before(since) {
+			continue
+		}
+		// while a log is being compressed there's both, and only the uncompressed one (which sorts first) is complete
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		files = append(files, backup)
+	}
+	files = append(files, path)
+
+	for _, file := range files {
+		if err := readFile(file, f); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func readFile(path string, f func(*Event)) error {
+	fp, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fp.Close()
+
+	var r io.Reader = fp
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(fp)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		f(&e)
+	}
+	return scanner.Err()
+}
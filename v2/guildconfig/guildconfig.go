@@ -0,0 +1,221 @@
+// Package guildconfig stores the settings server admins can change for their own server.
+package guildconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Leagues are the leagues that can be the default for rank commands.
+var Leagues = []string{"great", "ultra"}
+
+// Languages are the languages wobbotfet can speak.
+var Languages = []string{"en"}
+
+// MaxPrefixLength is the longest a command prefix can be.
+const MaxPrefixLength = 10
+
+// A Config is the configuration for a guild.
+type Config struct {
+	// DisabledCommands won't be run in the guild.
+	DisabledCommands []string `json:"disabled_commands"`
+	// DefaultLeague is used by rank commands when no league is given.
+	DefaultLeague string `json:"default_league"`
+	// CreateRoles is whether to create a role for a Pokemon the first time someone wants it. Existing roles are
+	// still added/removed.
+	CreateRoles bool `json:"create_roles"`
+	// RolePrefix is put in front of the Pokemon's name for want roles, ie `want-` for `@want-shieldon`.
+	RolePrefix string `json:"role_prefix"`
+	// PVPChannel is the ID of the channel to announce PVP battles in. If empty they're announced where they were
+	// asked for.
+	PVPChannel string `json:"pvp_channel"`
+	// Language is the language to respond in.
+	Language string `json:"language"`
+	// Prefix, if set, lets commands be run without mentioning wobbotfet, ie `!wob rank ...` for `!wob` or
+	// `!rank ...` for `!`.
+	Prefix string `json:"prefix"`
+	// LegacyAliases is whether v1's `!rank`, `!vrank` and `!betterthan` work without mentioning wobbotfet.
+	LegacyAliases bool `json:"legacy_aliases"`
+	// AnnounceChannel is the ID of the channel to post the owner's announcements (ie maintenance notices) in. If it's
+	// empty the guild doesn't get them.
+	AnnounceChannel string `json:"announce_channel"`
+	// Announcements is whether the guild wants the owner's announcements.
+	Announcements bool `json:"announcements"`
+	// UnknownCommands is whether to reply to commands wobbotfet doesn't have (with what might have been meant). Busy
+	// servers with a short prefix may not want a reply to every `!lol`.
+	UnknownCommands bool `json:"unknown_commands"`
+}
+
+// Default returns the configuration for a guild that hasn't changed anything.
+func Default() Config {
+	return Config{
+		DisabledCommands: []string{},
+		DefaultLeague:    "great",
+		CreateRoles:      true,
+		Language:         "en",
+		LegacyAliases:    true,
+		Announcements:    true,
+		UnknownCommands:  true,
+	}
+}
+
+// Enabled reports whether a command can be run.
+func (c Config) Enabled(command string) bool {
+	for _, v := range c.DisabledCommands {
+		if v == command {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate returns an error describing the first invalid setting.
+func (c Config) Validate() error {
+	if !contains(Leagues, c.DefaultLeague) {
+		return fmt.Errorf("`%s` isn't a league I support (try %s)", c.DefaultLeague, strings.Join(Leagues, ", "))
+	}
+	if !contains(Languages, c.Language) {
+		return fmt.Errorf("`%s` isn't a language I speak (try %s)", c.Language, strings.Join(Languages, ", "))
+	}
+	if strings.ContainsAny(c.RolePrefix, " @#") {
+		return fmt.Errorf("the role prefix can't have spaces, `@` or `#` in it")
+	}
+	if strings.ContainsAny(c.Prefix, " `") || len(c.Prefix) > MaxPrefixLength {
+		return fmt.Errorf("the command prefix can't have spaces or backticks in it, and can be at most %v characters", MaxPrefixLength)
+	}
+	return nil
+}
+
+// Enable removes a command from the disabled commands.
+func (c *Config) Enable(command string) {
+	var disabled []string
+	for _, v := range c.DisabledCommands {
+		if v != command {
+			disabled = append(disabled, v)
+		}
+	}
+	if disabled == nil {
+		disabled = []string{}
+	}
+	c.DisabledCommands = disabled
+}
+
+// Disable adds a command to the disabled commands.
+func (c *Config) Disable(command string) {
+	if !c.Enabled(command) {
+		return
+	}
+	c.DisabledCommands = append(c.DisabledCommands, command)
+	sort.Strings(c.DisabledCommands)
+}
+
+// A Store holds the configuration of every guild, saved as a JSON file.
+type Store struct {
+	sync.RWMutex
+	path   string
+	guilds map[string]Config
+}
+
+// Open loads the store at path. If the file doesn't exist it will be created the first time a guild is configured.
+func Open(path string) (*Store, error) {
+	s := &Store{
+		path:   path,
+		guilds: make(map[string]Config),
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var stored map[string]json.RawMessage
+	if err := json.Unmarshal(b, &stored); err != nil {
+		return nil, fmt.Errorf("error decoding %s: %w", path, err)
+	}
+	// start from the defaults so settings added since the file was written get them
+	for guild, raw := range stored {
+		c := Default()
+		if err := json.Unmarshal(raw, &c); err != nil {
+			return nil, fmt.Errorf("error decoding %s for guild %s: %w", path, guild, err)
+		}
+		s.guilds[guild] = c
+	}
+	return s, nil
+}
+
+// Get returns the configuration for a guild, or the default if it hasn't been configured.
+func (s *Store) Get(guild string) Config {
+	s.RLock()
+	defer s.RUnlock()
+
+	c, ok := s.guilds[guild]
+	if !ok {
+		return Default()
+	}
+	// don't let the caller modify what's stored
+	c.DisabledCommands = append([]string{}, c.DisabledCommands...)
+	return c
+}
+
+// Set validates and saves the configuration for a guild.
+func (s *Store) Set(guild string, c Config) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	previous, existed := s.guilds[guild]
+	s.guilds[guild] = c
+	if err := s.save(); err != nil {
+		if existed {
+			s.guilds[guild] = previous
+		} else {
+			delete(s.guilds, guild)
+		}
+		return err
+	}
+	return nil
+}
+
+// save writes the store to disk. It must be called with the lock held.
+func (s *Store) save() error {
+	b, err := json.MarshalIndent(s.guilds, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write to a temp file and rename it so a crash can't leave a half-written config
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -2,50 +2,80 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/Sigafoos/wobbotfet/api"
 	"github.com/Sigafoos/wobbotfet/bot"
+	"github.com/Sigafoos/wobbotfet/config"
 
 	"github.com/gorilla/mux"
 )
 
 func main() {
-	token := os.Getenv("DISCORD_TOKEN")
-	if token == "" {
-		log.Fatal("DISCORD_TOKEN not found in environment variables")
-	}
-	auth := "Bot " + token
+	configPath := flag.String("config", "", "path to the config file (defaults to config.yml, config.toml, etc in the working directory)")
+	healthcheck := flag.Bool("healthcheck", false, "check the health of a running wobbotfet and exit (for HEALTHCHECK in the Dockerfile)")
+	flag.Parse()
 
-	wob := bot.New(auth)
-	wob.Start()
+	// which of the config file's tokens to use, as in v1
+	env := "prod"
+	if flag.NArg() > 0 {
+		env = flag.Arg(0)
+	}
 
-	// DO NOT ENABLE THE API SERVER IF YOU ARE ON A PUBLIC NETWORK
-	host := os.Getenv("WOB_HOST")
-	if host == "" {
-		host = "0.0.0.0"
+	c, err := config.Load(*configPath, env)
+	if err != nil {
+		log.Fatal(err)
 	}
-	port := os.Getenv("WOB_PORT")
-	if port == "" {
-		port = "8081"
+	if *healthcheck {
+		os.Exit(checkHealth(c.Health))
 	}
+	for _, line := range c.Report() {
+		log.Println(line)
+	}
+
+	wob := bot.New(c)
+	a := api.New(wob, c.API)
+
+	// the health checks don't say anything private, so unlike the API they're always on
+	hr := mux.NewRouter()
+	hr.HandleFunc("/healthz", a.Health).Methods(http.MethodGet)
+	hr.HandleFunc("/readyz", a.Ready).Methods(http.MethodGet)
+	hs := &http.Server{
+		Addr:    net.JoinHostPort(c.Health.Host, c.Health.Port),
+		Handler: hr,
+	}
+	go serve("health check", hs)
+
+	wob.Start()
+
+	// SIGHUP reloads the config
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if _, err := wob.Reload(); err != nil {
+				log.Printf("error reloading config: %s", err)
+			}
+		}
+	}()
+
+	// without api.token or api.oauth, DO NOT ENABLE THE API SERVER IF YOU ARE ON A PUBLIC NETWORK
 	r := mux.NewRouter()
 	s := &http.Server{
-		Addr:    net.JoinHostPort(host, port),
+		Addr:    net.JoinHostPort(c.API.Host, c.API.Port),
 		Handler: r,
 	}
-	if os.Getenv("WOB_API") == "1" {
-		a := api.New(wob)
-		r.HandleFunc("/servers", a.GetServers).Methods(http.MethodGet)
-		r.HandleFunc("/pms", a.GetActivePMs).Methods(http.MethodGet)
-		r.HandleFunc("/servers/{server}/roles", a.GetRoles).Methods(http.MethodGet)
+	if c.API.Enabled {
+		a.Routes(r)
 
-		s.ListenAndServe()
+		go serve("API", s)
 	}
 
 	sc := make(chan os.Signal, 1)
@@ -55,4 +85,34 @@ func main() {
 	// quitting time. clean up after ourselves
 	wob.Close()
 	s.Shutdown(context.Background())
+	hs.Shutdown(context.Background())
+}
+
+// serve runs s until it's shut down.
+func serve(name string, s *http.Server) {
+	if err := s.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatalf("error running the %s server: %s", name, err)
+	}
+}
+
+// checkHealth asks the health check server whether wobbotfet is healthy, returning the exit code.
+func checkHealth(c config.Health) int {
+	host := c.Host
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get("http://" + net.JoinHostPort(host, c.Port) + "/healthz")
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("unhealthy: %s", resp.Status)
+		return 1
+	}
+	return 0
 }
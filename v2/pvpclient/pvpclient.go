@@ -0,0 +1,82 @@
+// Package pvpclient is a client for the PVP service (github.com/Sigafoos/pvpservice).
+package pvpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/Sigafoos/pvpservice/pvp"
+	"github.com/Sigafoos/wobbotfet/backend"
+)
+
+// Errors returned by the Client.
+var (
+	// ErrNotFound is returned when a player isn't registered.
+	ErrNotFound = backend.ErrNotFound
+	// ErrConflict is returned when a player, registration or friendship already exists.
+	ErrConflict = backend.ErrConflict
+)
+
+// A Client talks to the PVP service.
+type Client struct {
+	c *backend.Client
+}
+
+// New returns a Client for the PVP service at base (no trailing slash).
+func New(base string, opts ...backend.Option) *Client {
+	return &Client{
+		c: backend.New("pvp", base, opts...),
+	}
+}
+
+// Ping checks that the service is reachable.
+func (c *Client) Ping(ctx context.Context) error {
+	return c.c.Ping(ctx)
+}
+
+// Player returns a player by their Discord ID.
+func (c *Client) Player(ctx context.Context, id string) (*pvp.Player, error) {
+	var player pvp.Player
+	if err := c.c.Do(ctx, http.MethodGet, "/player?id="+url.QueryEscape(id), nil, &player); err != nil {
+		return nil, err
+	}
+	return &player, nil
+}
+
+// Players returns the players registered on a server.
+func (c *Client) Players(ctx context.Context, server string) ([]pvp.Player, error) {
+	var players []pvp.Player
+	err := c.c.Do(ctx, http.MethodGet, "/player/list?server="+url.QueryEscape(server), nil, &players)
+	if errors.Is(err, ErrNotFound) {
+		return players, nil
+	}
+	return players, err
+}
+
+// CreatePlayer creates a player.
+func (c *Client) CreatePlayer(ctx context.Context, player *pvp.Player) error {
+	return c.c.Do(ctx, http.MethodPost, "/player", player, nil, http.StatusCreated)
+}
+
+// Register registers a player on player.Server.
+func (c *Client) Register(ctx context.Context, player *pvp.Player) error {
+	// this is actually sending along all of a player's data when we really just need the server and user id
+	return c.c.Do(ctx, http.MethodPost, "/register", player, nil, http.StatusCreated)
+}
+
+// Friends returns the players a player is ultra friends with.
+func (c *Client) Friends(ctx context.Context, id string) ([]pvp.Player, error) {
+	var friends []pvp.Player
+	err := c.c.Do(ctx, http.MethodGet, "/player/friend?id="+url.QueryEscape(id), nil, &friends)
+	if errors.Is(err, ErrNotFound) {
+		return friends, nil
+	}
+	return friends, err
+}
+
+// AddFriend records an ultra friendship.
+func (c *Client) AddFriend(ctx context.Context, friendship *pvp.Friendship) error {
+	return c.c.Do(ctx, http.MethodPost, "/player/friend", friendship, nil, http.StatusCreated)
+}
@@ -0,0 +1,43 @@
+// Package rankclient is a client for the ranking service (github.com/Sigafoos/iv).
+package rankclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/Sigafoos/iv/model"
+	"github.com/Sigafoos/wobbotfet/backend"
+)
+
+// ErrNotFound is returned when the service doesn't know the Pokemon.
+var ErrNotFound = backend.ErrNotFound
+
+// A Client talks to the ranking service.
+type Client struct {
+	c *backend.Client
+}
+
+// New returns a Client for the ranking service at base (no trailing slash).
+func New(base string, opts ...backend.Option) *Client {
+	return &Client{
+		c: backend.New("rank", base, opts...),
+	}
+}
+
+// Ping checks that the service is reachable.
+func (c *Client) Ping(ctx context.Context) error {
+	return c.c.Ping(ctx)
+}
+
+// Rank returns the spread for a Pokemon's IVs in a league.
+func (c *Client) Rank(ctx context.Context, pokemon string, atk, def, hp int, league string) (*model.Spread, error) {
+	path := fmt.Sprintf("/iv?pokemon=%s&ivs=%v/%v/%v&league=%s", url.QueryEscape(pokemon), atk, def, hp, url.QueryEscape(league))
+
+	var spread model.Spread
+	if err := c.c.Do(ctx, http.MethodGet, path, nil, &spread); err != nil {
+		return nil, err
+	}
+	return &spread, nil
+}
@@ -0,0 +1,143 @@
+// Package ratelimit limits how often something can happen, with a token bucket for each key (ie a user).
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Limit is how many times something can happen in a period. The zero Limit is no limit.
+type Limit struct {
+	Count int
+	Per   time.Duration
+}
+
+// Unlimited reports whether the limit doesn't limit anything.
+func (l Limit) Unlimited() bool {
+	return l.Count == 0
+}
+
+func (l Limit) String() string {
+	if l.Unlimited() {
+		return "off"
+	}
+	return fmt.Sprintf("%d/%s", l.Count, l.Per)
+}
+
+// Parse parses a limit like `10/1m` (10 a minute). `off` or an empty string is no limit.
+func Parse(s string) (Limit, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "off" {
+		return Limit{}, nil
+	}
+
+	pieces := strings.SplitN(s, "/", 2)
+	if len(pieces) != 2 {
+		return Limit{}, fmt.Errorf("%q should be a count and a duration, ie 10/1m", s)
+	}
+	count, err := strconv.Atoi(pieces[0])
+	if err != nil || count < 1 {
+		return Limit{}, fmt.Errorf("%q should start with a positive count", s)
+	}
+	per, err := time.ParseDuration(pieces[1])
+	if err != nil || per <= 0 {
+		return Limit{}, fmt.Errorf("%q should end with a positive duration, ie 1m", s)
+	}
+	return Limit{Count: count, Per: per}, nil
+}
+
+// A Limiter limits how often each key can do something. Each key can do it Count times at once, and gets them back
+// at a steady rate, so it can do it Count times again after Per.
+type Limiter struct {
+	limit Limit
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	// swept is when the buckets were last cleared out.
+	swept time.Time
+	// now is the time, which tests can change.
+	now func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// New returns a Limiter for a limit.
+func New(l Limit) *Limiter {
+	return &Limiter{
+		limit:   l,
+		buckets: make(map[string]*bucket),
+		swept:   time.Now(),
+		now:     time.Now,
+	}
+}
+
+// Limit returns the limit being enforced.
+func (l *Limiter) Limit() Limit {
+	return l.limit
+}
+
+// Wait returns how long until key can do it again, or 0 if it can now. It doesn't use anything up; see Take.
+func (l *Limiter) Wait(key string) time.Duration {
+	if l.limit.Unlimited() {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.bucket(key, l.now())
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) * float64(l.limit.Per) / float64(l.limit.Count))
+}
+
+// Take uses up one of key's goes, even if it doesn't have any left.
+func (l *Limiter) Take(key string) {
+	if l.limit.Unlimited() {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.bucket(key, now).tokens--
+	l.sweep(now)
+}
+
+// bucket returns key's bucket, topped up to now.
+func (l *Limiter) bucket(key string, now time.Time) *bucket {
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.limit.Count), last: now}
+		l.buckets[key] = b
+		return b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() / l.limit.Per.Seconds() * float64(l.limit.Count)
+	if b.tokens > float64(l.limit.Count) {
+		b.tokens = float64(l.limit.Count)
+	}
+	b.last = now
+	return b
+}
+
+// sweep forgets the keys that are back to their full count, which are the same as new ones, once every Per.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < l.limit.Per {
+		return
+	}
+	for key := range l.buckets {
+		if l.bucket(key, now).tokens >= float64(l.limit.Count) {
+			delete(l.buckets, key)
+		}
+	}
+	l.swept = now
+}
@@ -0,0 +1,130 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Limit
+		err  bool
+	}{
+		{in: "10/1m", want: Limit{Count: 10, Per: time.Minute}},
+		{in: " 3/30s ", want: Limit{Count: 3, Per: 30 * time.Second}},
+		{in: "off", want: Limit{}},
+		{in: "", want: Limit{}},
+		{in: "10", err: true},
+		{in: "ten/1m", err: true},
+		{in: "0/1m", err: true},
+		{in: "-1/1m", err: true},
+		{in: "10/a minute", err: true},
+		{in: "10/0s", err: true},
+		{in: "10/-1m", err: true},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if tt.err {
+			if err == nil {
+				t.Errorf("Parse(%q) = %v, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q): %s", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+// newTestLimiter returns a limiter whose clock only moves when the returned function is called.
+func newTestLimiter(l Limit) (*Limiter, func(time.Duration)) {
+	limiter := New(l)
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+	return limiter, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestLimiter(t *testing.T) {
+	l, advance := newTestLimiter(Limit{Count: 2, Per: time.Minute})
+
+	for i := 0; i < 2; i++ {
+		if w := l.Wait("a"); w != 0 {
+			t.Fatalf("go %d: waiting %s", i+1, w)
+		}
+		l.Take("a")
+	}
+	// one go comes back every 30 seconds
+	if w := l.Wait("a"); w != 30*time.Second {
+		t.Errorf("waiting %s after using them up, want 30s", w)
+	}
+	if w := l.Wait("b"); w != 0 {
+		t.Errorf("another key waiting %s", w)
+	}
+
+	advance(20 * time.Second)
+	if w := l.Wait("a"); w != 10*time.Second {
+		t.Errorf("waiting %s after 20s, want 10s", w)
+	}
+	advance(10 * time.Second)
+	if w := l.Wait("a"); w != 0 {
+		t.Errorf("waiting %s after 30s", w)
+	}
+	l.Take("a")
+	if w := l.Wait("a"); w != 30*time.Second {
+		t.Errorf("waiting %s after using the one that came back, want 30s", w)
+	}
+
+	// it never has more than Count
+	advance(time.Hour)
+	l.Take("a")
+	l.Take("a")
+	if w := l.Wait("a"); w == 0 {
+		t.Error("not waiting after a long break and Count goes")
+	}
+}
+
+func TestWaitDoesntTake(t *testing.T) {
+	l, _ := newTestLimiter(Limit{Count: 1, Per: time.Minute})
+	for i := 0; i < 3; i++ {
+		if w := l.Wait("a"); w != 0 {
+			t.Fatalf("waiting %s after only waiting", w)
+		}
+	}
+}
+
+func TestUnlimited(t *testing.T) {
+	l := New(Limit{})
+	for i := 0; i < 100; i++ {
+		l.Take("a")
+	}
+	if w := l.Wait("a"); w != 0 {
+		t.Errorf("waiting %s without a limit", w)
+	}
+}
+
+func TestSweep(t *testing.T) {
+	l, advance := newTestLimiter(Limit{Count: 2, Per: time.Minute})
+	l.Take("a")
+	advance(40 * time.Second)
+	l.Take("b")
+	l.Take("b")
+
+	// a minute after the last sweep a's full again, but b's only had 20 seconds
+	advance(20 * time.Second)
+	l.Take("c")
+	if _, ok := l.buckets["a"]; ok {
+		t.Error("a is full, but wasn't swept")
+	}
+	if _, ok := l.buckets["b"]; !ok {
+		t.Error("b isn't full, but was swept")
+	}
+	if _, ok := l.buckets["c"]; !ok {
+		t.Error("c was just used, but was swept")
+	}
+}
@@ -0,0 +1,68 @@
+// Package wantclient is a client for the want service.
+package wantclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/Sigafoos/pokemongo"
+	"github.com/Sigafoos/wobbotfet/backend"
+)
+
+// Errors returned by the Client.
+var (
+	// ErrNotFound is returned when the Pokemon doesn't exist (or, for Unwant, isn't wanted).
+	ErrNotFound = backend.ErrNotFound
+	// ErrConflict is returned when the Pokemon is already wanted.
+	ErrConflict = backend.ErrConflict
+	// ErrForbidden is returned when the basic auth credentials are wrong.
+	ErrForbidden = backend.ErrForbidden
+)
+
+// A Request is the body sent when adding or removing a want.
+type Request struct {
+	User    string `json:"user,omitempty"`
+	Pokemon string `json:"pokemon,omitempty"`
+}
+
+// A Client talks to the want service.
+type Client struct {
+	c *backend.Client
+}
+
+// New returns a Client for the want service at base (no trailing slash).
+func New(base string, opts ...backend.Option) *Client {
+	return &Client{
+		c: backend.New("want", base, opts...),
+	}
+}
+
+// Ping checks that the service is reachable.
+func (c *Client) Ping(ctx context.Context) error {
+	return c.c.Ping(ctx)
+}
+
+// Want adds a Pokemon to a user's wants.
+func (c *Client) Want(ctx context.Context, user, pokemon string) error {
+	return c.c.Do(ctx, http.MethodPost, "/want", &Request{User: user, Pokemon: pokemon}, nil, http.StatusCreated)
+}
+
+// Unwant removes a Pokemon from a user's wants.
+func (c *Client) Unwant(ctx context.Context, user, pokemon string) error {
+	return c.c.Do(ctx, http.MethodDelete, "/want", &Request{User: user, Pokemon: pokemon}, nil)
+}
+
+// Wants returns the Pokemon a user wants.
+func (c *Client) Wants(ctx context.Context, user string) ([]pokemongo.Pokemon, error) {
+	var pokemon []pokemongo.Pokemon
+	err := c.c.Do(ctx, http.MethodGet, "/want?user="+url.QueryEscape(user), nil, &pokemon)
+	return pokemon, err
+}
+
+// Search returns the Pokemon whose names match name.
+func (c *Client) Search(ctx context.Context, name string) ([]pokemongo.Pokemon, error) {
+	var pokemon []pokemongo.Pokemon
+	err := c.c.Do(ctx, http.MethodGet, "/search?name="+url.QueryEscape(name), nil, &pokemon)
+	return pokemon, err
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// An event is a line of wobbotfet's access log. It mirrors eventlog.Event in the bot.
+type event struct {
+	Time          time.Time `json:"time"`
+	Guild         string    `json:"guild"`
+	Channel       string    `json:"channel"`
+	User          string    `json:"user"`
+	Command       string    `json:"command"`
+	Args          []string  `json:"args"`
+	Latency       float64   `json:"latency_ms"`
+	Outcome       string    `json:"outcome"`
+	BackendStatus []string  `json:"backend_status"`
+}
+
+// readEvents calls f for every event in the log at path, which can be gzipped (as rotated logs are). Lines from
+// before the log was JSON are parsed as well as they can be, by legacyEvent.
+func readEvents(path string, f func(*event)) error {
+	fp, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fp.Close()
+
+	var r io.Reader = fp
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(fp)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		text := scanner.Text()
+		if strings.HasPrefix(text, "{") {
+			var e event
+			if err := json.Unmarshal([]byte(text), &e); err != nil {
+				return err
+			}
+			f(&e)
+			continue
+		}
+
+		if e, ok := legacyEvent(text); ok {
+			f(e)
+		}
+	}
+	return scanner.Err()
+}
+
+// legacyTime is how lines were timestamped before the log was JSON.
+const legacyTime = "2006/01/02 15:04:05"
+
+// legacyEvent parses a line from before the log was JSON. v1 only logged rank, as
+// `date time \tguild\tchannel\tuser\tleague\tpokemon\tatk\tdef\thp`. v2 logged every command, as
+// `date time guild\tchannel\tuser\tcommand\targs...`, without a tab between the time and the guild. The user is
+// their name rather than their ID.
+func legacyEvent(text string) (*event, bool) {
+	line := strings.Split(text, "\t")
+	if len(line) < 4 {
+		return nil, false
+	}
+	prefix := strings.SplitN(line[0], " ", 3)
+	if len(prefix) < 3 {
+		return nil, false
+	}
+
+	e := &event{Args: nonEmpty(line[4:])}
+	if t, err := time.ParseInLocation(legacyTime, prefix[0]+" "+prefix[1], time.Local); err == nil {
+		e.Time = t
+	}
+	// a v2 PM has no guild either, but it has the user where v1 has the channel
+	if prefix[2] != "" || !isID(line[2]) {
+		e.Guild = prefix[2]
+		e.Channel = line[1]
+		e.User = line[2]
+		e.Command = line[3]
+	} else {
+		e.Guild = line[1]
+		e.Channel = line[2]
+		e.User = line[3]
+		e.Command = "rank"
+	}
+	return e, true
+}
+
+// isID reports whether s is a Discord ID.
+func isID(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// nonEmpty returns the strings that aren't empty, ie leaving out a rank league that wasn't given.
+func nonEmpty(ss []string) []string {
+	var out []string
+	for _, s := range ss {
+		if s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
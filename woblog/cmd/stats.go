@@ -1,11 +1,9 @@
 package cmd
 
 import (
-	"bufio"
 	"fmt"
 	"log"
 	"os"
-	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -28,38 +26,52 @@ var stats = &cobra.Command{
 
 // TODO open file over ssh?
 func runStats(cmd *cobra.Command, args []string) {
-	fp, err := os.Open(logFile)
-	if err != nil {
-		log.Println(err)
-		os.Exit(1)
-	}
-	defer fp.Close()
-
 	servers := make(map[string]*server)
-	scanner := bufio.NewScanner(fp)
-	for scanner.Scan() {
-		line := strings.Split(scanner.Text(), "\t")
-		if line[1] == "" {
-			continue
+	commands := make(map[string]int)
+	outcomes := make(map[string]int)
+	err := readEvents(logFile, func(e *event) {
+		commands[e.Command]++
+		if e.Outcome != "" {
+			outcomes[e.Outcome]++
+		}
+
+		if e.Guild == "" {
+			return
 		}
-		s, ok := servers[line[1]]
+		s, ok := servers[e.Guild]
 		if !ok {
-			guild, err := session().Guild(line[1])
+			guild, err := session().Guild(e.Guild)
 			if err != nil {
 				log.Println(err)
-				continue
+				return
 			}
 			s = &server{
-				ID:    line[1],
+				ID:    e.Guild,
 				Name:  guild.Name,
 				Count: 0,
 			}
-			servers[line[1]] = s
+			servers[e.Guild] = s
 		}
 		s.Count++
+	})
+	if err != nil {
+		log.Println(err)
+		os.Exit(1)
 	}
 
 	for _, v := range servers {
 		fmt.Printf("%v: %s\n", v.Count, v.Name)
 	}
+
+	fmt.Println()
+	for k, v := range commands {
+		fmt.Printf("%v: %s\n", v, k)
+	}
+
+	if len(outcomes) > 0 {
+		fmt.Println()
+		for k, v := range outcomes {
+			fmt.Printf("%v: %s\n", v, k)
+		}
+	}
 }